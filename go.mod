@@ -1,3 +1,11 @@
 module github.com/mvity/go-box
 
-go 1.25
+go 1.25.0
+
+require (
+	github.com/emmansun/gmsm v0.41.1
+	golang.org/x/crypto v0.55.0
+	software.sslmate.com/src/go-pkcs12 v0.7.3
+)
+
+require golang.org/x/sys v0.47.0 // indirect
@@ -0,0 +1,302 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/emmansun/gmsm/sm4"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	ErrAEADKeySize     = errors.New("x: invalid AEAD key size")              // AEAD 密钥长度错误
+	ErrAEADDecrypt     = errors.New("x: AEAD message authentication failed") // 解密失败：密钥错误、附加数据不一致或密文被篡改
+	ErrAEADEnvelope    = errors.New("x: invalid AEAD envelope")              // 信封格式错误或版本不支持
+	ErrAEADKeyNotFound = errors.New("x: AEAD key not found in keyring")      // 密钥环中不存在信封使用的密钥
+	ErrAEADAlgorithm   = errors.New("x: unsupported AEAD algorithm")         // 不支持的算法
+)
+
+// AEADAlgorithm AEAD 算法
+type AEADAlgorithm byte
+
+const (
+	AEADAESGCM           AEADAlgorithm = 1 // AES-GCM，key：16、24、32（AES-128/192/256），nonce：12
+	AEADChaCha20Poly1305 AEADAlgorithm = 2 // ChaCha20-Poly1305（RFC 8439），key：32，nonce：12
+	AEADSM4GCM           AEADAlgorithm = 3 // SM4-GCM（GB/T 32907），key：16，nonce：12
+)
+
+// aeadEnvelopeVersion 信封格式版本
+const aeadEnvelopeVersion byte = 1
+
+// aeadEnvelopeHeader 信封头长度：版本（1）+ 算法（1）+ 密钥 ID（4）
+const aeadEnvelopeHeader = 6
+
+// AESGCMEncrypt AES-GCM 加密，key：16、24、32，返回 随机 nonce（12）+ 密文 + 认证标签（16），aad 为可选附加数据
+func AESGCMEncrypt(key, plain, aad []byte) ([]byte, error) {
+	return aeadSeal(AEADAESGCM, key, plain, aad)
+}
+
+// AESGCMDecrypt AES-GCM 解密 AESGCMEncrypt 的结果，aad 须与加密时一致，认证失败时返回 ErrAEADDecrypt
+func AESGCMDecrypt(key, data, aad []byte) ([]byte, error) {
+	return aeadOpen(AEADAESGCM, key, data, aad)
+}
+
+// ChaCha20Poly1305Encrypt ChaCha20-Poly1305 加密，key：32，返回 随机 nonce（12）+ 密文 + 认证标签（16），aad 为可选附加数据
+func ChaCha20Poly1305Encrypt(key, plain, aad []byte) ([]byte, error) {
+	return aeadSeal(AEADChaCha20Poly1305, key, plain, aad)
+}
+
+// ChaCha20Poly1305Decrypt ChaCha20-Poly1305 解密 ChaCha20Poly1305Encrypt 的结果，aad 须与加密时一致，认证失败时返回 ErrAEADDecrypt
+func ChaCha20Poly1305Decrypt(key, data, aad []byte) ([]byte, error) {
+	return aeadOpen(AEADChaCha20Poly1305, key, data, aad)
+}
+
+// aeadNew 创建 AEAD
+func aeadNew(alg AEADAlgorithm, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case AEADAESGCM:
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("%w: AES-GCM key must be 16, 24 or 32 bytes, got %d", ErrAEADKeySize, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case AEADChaCha20Poly1305:
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("%w: ChaCha20-Poly1305 key must be 32 bytes, got %d", ErrAEADKeySize, len(key))
+		}
+		return chacha20poly1305.New(key)
+	case AEADSM4GCM:
+		if len(key) != sm4.BlockSize {
+			return nil, fmt.Errorf("%w: SM4-GCM key must be 16 bytes, got %d", ErrAEADKeySize, len(key))
+		}
+		block, err := sm4.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	}
+	return nil, fmt.Errorf("%w: %d", ErrAEADAlgorithm, alg)
+}
+
+// aeadSeal 加密并将随机 nonce 置于密文之前
+func aeadSeal(alg AEADAlgorithm, key, plain, aad []byte) ([]byte, error) {
+	aead, err := aeadNew(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, aad), nil
+}
+
+// aeadOpen 解密 nonce + 密文
+func aeadOpen(alg AEADAlgorithm, key, data, aad []byte) ([]byte, error) {
+	aead, err := aeadNew(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrAEADDecrypt
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], aad)
+	if err != nil {
+		return nil, ErrAEADDecrypt
+	}
+	return plain, nil
+}
+
+// AEADKeyring AEAD 密钥环，支持密钥轮换：使用主密钥加密，按信封中的密钥 ID 选择密钥解密。
+// 信封格式：版本（1）+ 算法（1）+ 密钥 ID（4，大端）+ nonce + 密文 + 认证标签，信封头同时作为附加数据参与认证。
+// 可安全地并发使用
+type AEADKeyring struct {
+	mu      sync.RWMutex
+	keys    map[uint32]aeadKey
+	primary uint32
+}
+
+// aeadKey 密钥环中的密钥
+type aeadKey struct {
+	alg  AEADAlgorithm
+	aead cipher.AEAD
+}
+
+// NewAEADKeyring 创建空密钥环，第一个添加的密钥为主密钥
+func NewAEADKeyring() *AEADKeyring {
+	return &AEADKeyring{keys: make(map[uint32]aeadKey)}
+}
+
+// Add 添加密钥，id 不能重复，第一个添加的密钥自动成为主密钥
+func (k *AEADKeyring) Add(id uint32, alg AEADAlgorithm, key []byte) error {
+	aead, err := aeadNew(alg, key)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("x: AEAD key %d already exists", id)
+	}
+	if len(k.keys) == 0 {
+		k.primary = id
+	}
+	k.keys[id] = aeadKey{alg: alg, aead: aead}
+	return nil
+}
+
+// SetPrimary 设置主密钥，新数据均使用主密钥加密
+func (k *AEADKeyring) SetPrimary(id uint32) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrAEADKeyNotFound, id)
+	}
+	k.primary = id
+	return nil
+}
+
+// Remove 移除密钥，不能移除主密钥，移除后该密钥加密的数据将无法解密
+func (k *AEADKeyring) Remove(id uint32) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.primary {
+		return fmt.Errorf("x: cannot remove primary AEAD key %d", id)
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Primary 当前主密钥 ID
+func (k *AEADKeyring) Primary() uint32 {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// Encrypt 使用主密钥加密并返回信封，aad 为可选附加数据，例：用户 ID，解密时须一致
+func (k *AEADKeyring) Encrypt(plain, aad []byte) ([]byte, error) {
+	k.mu.RLock()
+	id, key, ok := k.primary, k.keys[k.primary], len(k.keys) > 0
+	k.mu.RUnlock()
+	if !ok {
+		return nil, ErrAEADKeyNotFound
+	}
+	header := make([]byte, aeadEnvelopeHeader, aeadEnvelopeHeader+key.aead.NonceSize()+len(plain)+key.aead.Overhead())
+	header[0], header[1] = aeadEnvelopeVersion, byte(key.alg)
+	binary.BigEndian.PutUint32(header[2:], id)
+	nonce := header[aeadEnvelopeHeader : aeadEnvelopeHeader+key.aead.NonceSize()]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := header[:aeadEnvelopeHeader+len(nonce)]
+	return key.aead.Seal(out, nonce, plain, aeadEnvelopeAAD(header[:aeadEnvelopeHeader], aad)), nil
+}
+
+// Decrypt 解密信封，aad 须与加密时一致
+func (k *AEADKeyring) Decrypt(envelope, aad []byte) ([]byte, error) {
+	key, err := k.envelopeKey(envelope)
+	if err != nil {
+		return nil, err
+	}
+	body := envelope[aeadEnvelopeHeader:]
+	if len(body) < key.aead.NonceSize()+key.aead.Overhead() {
+		return nil, ErrAEADEnvelope
+	}
+	nonce := body[:key.aead.NonceSize()]
+	plain, err := key.aead.Open(nil, nonce, body[key.aead.NonceSize():], aeadEnvelopeAAD(envelope[:aeadEnvelopeHeader], aad))
+	if err != nil {
+		return nil, ErrAEADDecrypt
+	}
+	return plain, nil
+}
+
+// EncryptString 使用主密钥加密字符串并返回 Base64 信封，例：加密身份证号
+func (k *AEADKeyring) EncryptString(plain, aad string) (string, error) {
+	envelope, err := k.Encrypt([]byte(plain), []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// DecryptString 解密 Base64 信封并返回字符串
+func (k *AEADKeyring) DecryptString(envelope, aad string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(envelope)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAEADEnvelope, err)
+	}
+	plain, err := k.Decrypt(data, []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// KeyID 获取信封使用的密钥 ID
+func (k *AEADKeyring) KeyID(envelope []byte) (uint32, error) {
+	if len(envelope) < aeadEnvelopeHeader || envelope[0] != aeadEnvelopeVersion {
+		return 0, ErrAEADEnvelope
+	}
+	return binary.BigEndian.Uint32(envelope[2:aeadEnvelopeHeader]), nil
+}
+
+// NeedsRotation 信封是否使用非主密钥加密，需要调用 Rotate 重新加密
+func (k *AEADKeyring) NeedsRotation(envelope []byte) bool {
+	id, err := k.KeyID(envelope)
+	return err == nil && id != k.Primary()
+}
+
+// Rotate 解密信封并使用主密钥重新加密，信封已使用主密钥时原样返回
+func (k *AEADKeyring) Rotate(envelope, aad []byte) ([]byte, error) {
+	plain, err := k.Decrypt(envelope, aad)
+	if err != nil {
+		return nil, err
+	}
+	if !k.NeedsRotation(envelope) {
+		return envelope, nil
+	}
+	return k.Encrypt(plain, aad)
+}
+
+// envelopeKey 解析信封头并获取密钥
+func (k *AEADKeyring) envelopeKey(envelope []byte) (aeadKey, error) {
+	id, err := k.KeyID(envelope)
+	if err != nil {
+		return aeadKey{}, err
+	}
+	k.mu.RLock()
+	key, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		return aeadKey{}, fmt.Errorf("%w: %d", ErrAEADKeyNotFound, id)
+	}
+	if AEADAlgorithm(envelope[1]) != key.alg {
+		return aeadKey{}, fmt.Errorf("%w: algorithm mismatch for key %d", ErrAEADEnvelope, id)
+	}
+	return key, nil
+}
+
+// aeadEnvelopeAAD 信封头与附加数据拼接为实际附加数据
+func aeadEnvelopeAAD(header, aad []byte) []byte {
+	out := make([]byte, 0, len(header)+len(aad))
+	return append(append(out, header...), aad...)
+}
@@ -1,5 +1,5 @@
 /*
- * Copyright © 2021 - 2023 vity <vityme@icloud.com>.
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
  *
  * Use of this source code is governed by an MIT-style
  * license that can be found in the LICENSE file.
@@ -11,36 +11,151 @@ import (
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
 	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/emmansun/gmsm/sm3"
+)
+
+var (
+	ErrAESKeySize    = errors.New("x: invalid AES key size, must be 16, 24 or 32 bytes") // AES 密钥长度错误
+	ErrAESIVSize     = errors.New("x: invalid AES iv size, must be 16 bytes")            // AES 向量长度错误
+	ErrAESCiphertext = errors.New("x: invalid AES ciphertext")                           // AES 密文格式错误
+	ErrAESPadding    = errors.New("x: invalid AES padding")                              // AES 填充错误，密钥错误或密文损坏
 )
 
-// AESEncrypt AES 加密内容 AES-128/CBC/PKCS5Padding ，key：32，iv：16
+// AESEncrypt AES 加密内容 AES/CBC/PKCS7Padding，key：16、24、32（AES-128/192/256），iv：16，返回 Base64，出错时返回空字符串
 func AESEncrypt(key string, iv string, data string) string {
-	block, _ := aes.NewCipher([]byte(key))
-	cbc := cipher.NewCBCEncrypter(block, []byte(iv))
-	content := []byte(data)
-	padding := block.BlockSize() - len(content)%block.BlockSize()
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	content = append(content, padtext...)
-	crypted := make([]byte, len(content))
-	cbc.CryptBlocks(crypted, content)
-	return base64.StdEncoding.EncodeToString(crypted)
-}
-
-// AESDecrypt AES 解密内容 AES-128/CBC/PKCS5Padding ，key：32，iv：16
+	crypted, _ := AESEncryptE(key, iv, data)
+	return crypted
+}
+
+// AESDecrypt AES 解密内容 AES/CBC/PKCS7Padding，key：16、24、32（AES-128/192/256），iv：16，data 为 Base64，出错时返回空字符串
 func AESDecrypt(key string, iv string, data string) string {
-	crypt, _ := base64.StdEncoding.DecodeString(data)
-	block, _ := aes.NewCipher([]byte(key))
-	cbc := cipher.NewCBCDecrypter(block, []byte(iv))
-	decrypted := make([]byte, len(crypt))
-	cbc.CryptBlocks(decrypted, crypt)
-	padding := decrypted[len(decrypted)-1]
-	return string(decrypted[:len(decrypted)-int(padding)])
+	plain, _ := AESDecryptE(key, iv, data)
+	return plain
+}
+
+// AESEncryptE AES 加密内容 AES/CBC/PKCS7Padding，返回 Base64 密文及错误
+func AESEncryptE(key string, iv string, data string) (string, error) {
+	crypted, err := AESCBCEncrypt([]byte(key), []byte(iv), []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(crypted), nil
+}
+
+// AESDecryptE AES 解密 Base64 密文 AES/CBC/PKCS7Padding，返回明文及错误
+func AESDecryptE(key string, iv string, data string) (string, error) {
+	crypted, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAESCiphertext, err)
+	}
+	plain, err := AESCBCDecrypt([]byte(key), []byte(iv), crypted)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// AESEncryptHex AES 加密内容 AES/CBC/PKCS7Padding，返回十六进制密文及错误
+func AESEncryptHex(key string, iv string, data string) (string, error) {
+	crypted, err := AESCBCEncrypt([]byte(key), []byte(iv), []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(crypted), nil
+}
+
+// AESDecryptHex AES 解密十六进制密文 AES/CBC/PKCS7Padding，返回明文及错误
+func AESDecryptHex(key string, iv string, data string) (string, error) {
+	crypted, err := hex.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrAESCiphertext, err)
+	}
+	plain, err := AESCBCDecrypt([]byte(key), []byte(iv), crypted)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// AESCBCEncrypt AES 加密 AES/CBC/PKCS7Padding，key：16、24、32（AES-128/192/256），iv：16
+func AESCBCEncrypt(key, iv, plain []byte) ([]byte, error) {
+	block, err := aesBlock(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	content := pkcs7Pad(plain, aes.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(content, content)
+	return content, nil
+}
+
+// AESCBCDecrypt AES 解密 AES/CBC/PKCS7Padding，key：16、24、32（AES-128/192/256），iv：16，
+// 填充以常量时间校验，校验失败时返回 ErrAESPadding
+func AESCBCDecrypt(key, iv, crypted []byte) ([]byte, error) {
+	block, err := aesBlock(key, iv)
+	if err != nil {
+		return nil, err
+	}
+	if len(crypted) == 0 || len(crypted)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: length %d is not a multiple of %d", ErrAESCiphertext, len(crypted), aes.BlockSize)
+	}
+	decrypted := make([]byte, len(crypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, crypted)
+	return pkcs7Unpad(decrypted, aes.BlockSize)
+}
+
+// aesBlock 校验密钥及向量长度并创建 AES 分组
+func aesBlock(key, iv []byte) (cipher.Block, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("%w: got %d", ErrAESKeySize, len(key))
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("%w: got %d", ErrAESIVSize, len(iv))
+	}
+	return aes.NewCipher(key)
+}
+
+// pkcs7Pad 复制内容并追加 PKCS#7 填充
+func pkcs7Pad(plain []byte, blockSize int) []byte {
+	padding := blockSize - len(plain)%blockSize
+	content := make([]byte, len(plain), len(plain)+padding)
+	copy(content, plain)
+	return append(content, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// pkcs7Unpad 以常量时间校验并去除 PKCS#7 填充，避免填充预言攻击
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	n := len(data)
+	if n == 0 || n%blockSize != 0 {
+		return nil, ErrAESPadding
+	}
+	padding := int(data[n-1])
+	good := subtle.ConstantTimeLessOrEq(1, padding) & subtle.ConstantTimeLessOrEq(padding, blockSize)
+	for i := 0; i < blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i+1, padding)
+		match := subtle.ConstantTimeByteEq(data[n-1-i], byte(padding))
+		good &= subtle.ConstantTimeSelect(inPadding, match, 1)
+	}
+	if good != 1 {
+		return nil, ErrAESPadding
+	}
+	return data[:n-padding], nil
 }
 
 //
@@ -119,9 +234,180 @@ func SHA256String(plain string) string {
 	return hex.EncodeToString(hSha256.Sum(nil))
 }
 
+// SM3String  对字符串SM3处理（GB/T 32905）
+func SM3String(plain string) string {
+	hSm3 := sm3.New()
+	hSm3.Write([]byte(plain))
+	return hex.EncodeToString(hSm3.Sum(nil))
+}
+
 // SHA512String  对字符串SHA512处理
 func SHA512String(plain string) string {
 	hSha512 := sha512.New()
 	hSha512.Write([]byte(plain))
 	return hex.EncodeToString(hSha512.Sum(nil))
 }
+
+// HMACMD5String 对字符串HMAC-MD5处理
+func HMACMD5String(key string, plain string) string {
+	return hmacString(md5.New, key, plain)
+}
+
+// HMACSHA1String 对字符串HMAC-SHA1处理
+func HMACSHA1String(key string, plain string) string {
+	return hmacString(sha1.New, key, plain)
+}
+
+// HMACSHA256String 对字符串HMAC-SHA256处理
+func HMACSHA256String(key string, plain string) string {
+	return hmacString(sha256.New, key, plain)
+}
+
+// HMACSHA512String 对字符串HMAC-SHA512处理
+func HMACSHA512String(key string, plain string) string {
+	return hmacString(sha512.New, key, plain)
+}
+
+// hmacString 计算 HMAC 并返回十六进制结果
+func hmacString(h func() hash.Hash, key string, plain string) string {
+	mac := hmac.New(h, []byte(key))
+	mac.Write([]byte(plain))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashAlgorithm 摘要算法
+type HashAlgorithm string
+
+const (
+	HashMD5    HashAlgorithm = "md5"
+	HashSHA1   HashAlgorithm = "sha1"
+	HashSHA256 HashAlgorithm = "sha256"
+	HashSHA512 HashAlgorithm = "sha512"
+	HashSM3    HashAlgorithm = "sm3"
+	HashCRC32  HashAlgorithm = "crc32" // CRC-32（IEEE），结果为 8 位十六进制
+)
+
+// MD5Reader 流式读取内容并MD5处理
+func MD5Reader(r io.Reader) (string, error) {
+	return hashReaderOne(r, HashMD5)
+}
+
+// SHA1Reader 流式读取内容并SHA1处理
+func SHA1Reader(r io.Reader) (string, error) {
+	return hashReaderOne(r, HashSHA1)
+}
+
+// SHA256Reader 流式读取内容并SHA256处理
+func SHA256Reader(r io.Reader) (string, error) {
+	return hashReaderOne(r, HashSHA256)
+}
+
+// SHA512Reader 流式读取内容并SHA512处理
+func SHA512Reader(r io.Reader) (string, error) {
+	return hashReaderOne(r, HashSHA512)
+}
+
+// CRC32Reader 流式读取内容并CRC32处理
+func CRC32Reader(r io.Reader) (string, error) {
+	return hashReaderOne(r, HashCRC32)
+}
+
+// MD5File 对文件MD5处理
+func MD5File(path string) (string, error) {
+	return hashFileOne(path, HashMD5)
+}
+
+// SHA1File 对文件SHA1处理
+func SHA1File(path string) (string, error) {
+	return hashFileOne(path, HashSHA1)
+}
+
+// SHA256File 对文件SHA256处理
+func SHA256File(path string) (string, error) {
+	return hashFileOne(path, HashSHA256)
+}
+
+// SHA512File 对文件SHA512处理
+func SHA512File(path string) (string, error) {
+	return hashFileOne(path, HashSHA512)
+}
+
+// CRC32File 对文件CRC32处理
+func CRC32File(path string) (string, error) {
+	return hashFileOne(path, HashCRC32)
+}
+
+// HashReader 单次读取内容同时计算多个摘要，返回算法对应的十六进制结果，例：
+//
+//	sums, err := x.HashReader(r, x.HashMD5, x.HashSHA256)
+//	sums[x.HashSHA256]
+func HashReader(r io.Reader, algs ...HashAlgorithm) (map[HashAlgorithm]string, error) {
+	hashes := make(map[HashAlgorithm]hash.Hash, len(algs))
+	writers := make([]io.Writer, 0, len(algs))
+	for _, alg := range algs {
+		if _, ok := hashes[alg]; ok {
+			continue
+		}
+		h, err := hashNew(alg)
+		if err != nil {
+			return nil, err
+		}
+		hashes[alg] = h
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, err
+	}
+	sums := make(map[HashAlgorithm]string, len(hashes))
+	for alg, h := range hashes {
+		sums[alg] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
+
+// HashFile 单次读取文件同时计算多个摘要，例：校验 HttpPostJsonDownload 下载的文件
+func HashFile(path string, algs ...HashAlgorithm) (map[HashAlgorithm]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return HashReader(file, algs...)
+}
+
+// hashReaderOne 流式计算单个摘要
+func hashReaderOne(r io.Reader, alg HashAlgorithm) (string, error) {
+	sums, err := HashReader(r, alg)
+	if err != nil {
+		return "", err
+	}
+	return sums[alg], nil
+}
+
+// hashFileOne 计算文件的单个摘要
+func hashFileOne(path string, alg HashAlgorithm) (string, error) {
+	sums, err := HashFile(path, alg)
+	if err != nil {
+		return "", err
+	}
+	return sums[alg], nil
+}
+
+// hashNew 创建摘要算法
+func hashNew(alg HashAlgorithm) (hash.Hash, error) {
+	switch alg {
+	case HashMD5:
+		return md5.New(), nil
+	case HashSHA1:
+		return sha1.New(), nil
+	case HashSHA256:
+		return sha256.New(), nil
+	case HashSHA512:
+		return sha512.New(), nil
+	case HashSM3:
+		return sm3.New(), nil
+	case HashCRC32:
+		return crc32.NewIEEE(), nil
+	}
+	return nil, fmt.Errorf("x: unsupported hash algorithm %q", alg)
+}
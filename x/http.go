@@ -1,5 +1,5 @@
 /*
- * Copyright © 2021 - 2024 vity <vityme@icloud.com>.
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
  *
  * Use of this source code is governed by an MIT-style
  * license that can be found in the LICENSE file.
@@ -8,10 +8,7 @@
 package x
 
 import (
-	"bytes"
-	"crypto/tls"
-	"crypto/x509"
-	"fmt"
+	"context"
 	"io"
 	"net/http"
 	"net/url"
@@ -21,185 +18,130 @@ import (
 
 // HttpGet 执行Get请求
 func HttpGet(requestUrl string) (bool, string, int) {
-	req, err := http.NewRequest("GET", requestUrl, nil)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpGet", "Network Create Error："+err.Error()), 0
-	}
-	client := http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpGet", "Network Request Error："+err.Error()), 0
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpGet", "Network Response Error："+err.Error()), resp.StatusCode
-	}
-	return true, string(body), resp.StatusCode
+	return HttpDefaultClient().send("x.HttpGet", http.MethodGet, requestUrl, nil, "", nil)
+}
+
+// HttpGetContext 执行Get请求，支持 context 取消及超时
+func HttpGetContext(ctx context.Context, requestUrl string) (string, int, error) {
+	return HttpDefaultClient().sendContext(ctx, "x.HttpGetContext", http.MethodGet, requestUrl, nil, "", nil)
 }
 
 // HttpPostForm 执行Post Form 请求
 func HttpPostForm(requestUrl string, data url.Values) (bool, string, int) {
-	form := io.NopCloser(strings.NewReader(data.Encode()))
-	req, err := http.NewRequest("POST", requestUrl, form)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostForm", "Network Create Error："+err.Error()), 0
-	}
-	client := http.Client{}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=utf-8")
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostForm", "Network Request Error："+err.Error()), 0
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostForm", "Network Response Error："+err.Error()), 0
-	}
-	return true, string(body), resp.StatusCode
-
-	//client := http.Client{}
-	//resp, err := client.PostForm(requestUrl, data)
-	//if err != nil {
-	//	return false, fmt.Sprintf("[%s] %s", "x.HttpPostForm", "Network Request Error："+err.Error()), 0
-	//}
-	//defer func(Body io.ReadCloser) {
-	//	_ = Body.Close()
-	//}(resp.Body)
-	//body, err := io.ReadAll(resp.Body)
-	//if err != nil {
-	//	return false, fmt.Sprintf("[%s] %s", "x.HttpPostForm", "Network Response Error："+err.Error()), 0
-	//}
-	//return true, string(body), resp.StatusCode
+	return HttpDefaultClient().send("x.HttpPostForm", http.MethodPost, requestUrl, strings.NewReader(data.Encode()),
+		httpContentTypeForm, nil)
+}
+
+// HttpPostFormContext 执行Post Form 请求，支持 context 取消及超时
+func HttpPostFormContext(ctx context.Context, requestUrl string, data url.Values) (string, int, error) {
+	return HttpDefaultClient().sendContext(ctx, "x.HttpPostFormContext", http.MethodPost, requestUrl, strings.NewReader(data.Encode()),
+		httpContentTypeForm, nil)
 }
 
 // HttpPostJson 执行Post JSON请求
 func HttpPostJson(requestUrl string, json string) (bool, string, int) {
-	req, err := http.NewRequest("POST", requestUrl, bytes.NewBuffer([]byte(json)))
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostJson", "Network Create Error："+err.Error()), 0
-	}
-	client := http.Client{}
-	req.Header.Set("Content-Type", "application/json;charset=utf-8")
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostJson", "Network Request Error："+err.Error()), 0
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostJson", "Network Response Error："+err.Error()), 0
-	}
-	return true, string(body), resp.StatusCode
+	return HttpDefaultClient().send("x.HttpPostJson", http.MethodPost, requestUrl, strings.NewReader(json),
+		httpContentTypeJson, nil)
+}
+
+// HttpPostJsonContext 执行Post JSON请求，支持 context 取消及超时
+func HttpPostJsonContext(ctx context.Context, requestUrl string, json string) (string, int, error) {
+	return HttpDefaultClient().sendContext(ctx, "x.HttpPostJsonContext", http.MethodPost, requestUrl, strings.NewReader(json),
+		httpContentTypeJson, nil)
 }
 
 // HttpPostJsonWithHeader 执行Post JSON请求
 func HttpPostJsonWithHeader(requestUrl string, json string, headers map[string]string) (bool, string, int) {
-	req, err := http.NewRequest("POST", requestUrl, bytes.NewBuffer([]byte(json)))
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostJsonWithHeader", "Network Create Error："+err.Error()), 0
-	}
-	client := http.Client{}
-	req.Header.Set("Content-Type", "application/json")
-	for key, val := range headers {
-		req.Header.Set(key, val)
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostJsonWithHeader", "Network Request Error："+err.Error()), 0
-	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostJsonWithHeader", "Network Response Error："+err.Error()), 0
-	}
-	return true, string(body), resp.StatusCode
+	return HttpDefaultClient().send("x.HttpPostJsonWithHeader", http.MethodPost, requestUrl, strings.NewReader(json),
+		"application/json", headers)
+}
+
+// HttpPostJsonWithHeaderContext 执行Post JSON请求，支持 context 取消及超时
+func HttpPostJsonWithHeaderContext(ctx context.Context, requestUrl string, json string, headers map[string]string) (string, int, error) {
+	return HttpDefaultClient().sendContext(ctx, "x.HttpPostJsonWithHeaderContext", http.MethodPost, requestUrl, strings.NewReader(json),
+		"application/json", headers)
 }
 
 // HttpPostXmlSecure 执行Post XML 证书请求
 func HttpPostXmlSecure(requestUrl string, xml string, certFile string, keyFile string, rootCaFile string) (bool, string, int) {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostXmlSecure", "Init Cert & Key error:"+err.Error()), 0
-	}
-	root, err := os.ReadFile(rootCaFile)
+	data, code, err := httpPostXmlSecure(context.Background(), "x.HttpPostXmlSecure", requestUrl, xml, certFile, keyFile, rootCaFile)
 	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostXmlSecure", "Init Rootca Cert error:"+err.Error()), 0
-	}
-	pool := x509.NewCertPool()
-	pool.AppendCertsFromPEM(root)
-	conf := &tls.Config{
-		Certificates: []tls.Certificate{cert},
+		return false, err.Error(), code
 	}
-	c := &http.Client{Transport: &http.Transport{TLSClientConfig: conf}}
+	return true, data, code
+}
 
-	req, err := http.NewRequest(http.MethodPost, requestUrl, bytes.NewBuffer([]byte(xml)))
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostXmlSecure", "Network Create Error："+err.Error()), 0
-	}
-	req.Header.Set("Content-Type", "application/json")
+// HttpPostXmlSecureContext 执行Post XML 证书请求，支持 context 取消及超时
+func HttpPostXmlSecureContext(ctx context.Context, requestUrl string, xml string, certFile string, keyFile string, rootCaFile string) (string, int, error) {
+	return httpPostXmlSecure(ctx, "x.HttpPostXmlSecureContext", requestUrl, xml, certFile, keyFile, rootCaFile)
+}
 
-	resp, err := c.Do(req)
-	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostXmlSecure", "Network Request Error："+err.Error()), 0
+// httpPostXmlSecure 执行Post XML 证书请求，op 用于错误信息前缀
+func httpPostXmlSecure(ctx context.Context, op, requestUrl string, xml string, certFile string, keyFile string, rootCaFile string) (string, int, error) {
+	opts := []HttpTLSOption{HttpTLSClientCertFile(certFile, keyFile)}
+	if rootCaFile != "" {
+		opts = append(opts, HttpTLSRootCAFile(rootCaFile))
 	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(resp.Body)
-	body, err := io.ReadAll(resp.Body)
+	conf, err := NewHttpTLSConfig(opts...)
 	if err != nil {
-		return false, fmt.Sprintf("[%s] %s", "x.HttpPostXmlSecure", "Network Response Error："+err.Error()), 0
+		return "", 0, &HttpError{Op: op, Msg: "Init Cert Error", Err: err}
 	}
-	return true, string(body), resp.StatusCode
+	c := NewHttpClient(HttpWithTLSConfig(conf))
+	defer c.CloseIdleConnections()
+	return c.sendContext(ctx, op, http.MethodPost, requestUrl, strings.NewReader(xml), httpContentTypeXml, nil)
 }
 
 // HttpPostJsonDownload 执行Post JSON请求并获取响应文件
 func HttpPostJsonDownload(requestUrl string, json string) (bool, string, *os.File, int) {
-	req, err := http.NewRequest("POST", requestUrl, bytes.NewBuffer([]byte(json)))
+	msg, f, code, err := HttpDefaultClient().postJsonDownload(context.Background(), "x.HttpPostJsonDownload", requestUrl, json)
 	if err != nil {
-		return false, fmt.Sprintf("[%s] %s",
-			"x.HttpPostJsonDownload", "Network Create Error："+err.Error(),
-		), nil, 0
+		return false, err.Error(), nil, code
 	}
-	client := http.Client{}
-	req.Header.Set("Content-Type", "application/json;charset=utf-8")
-	resp, err := client.Do(req)
+	if f == nil {
+		return false, msg, nil, code
+	}
+	return true, "", f, code
+}
+
+// HttpPostJsonDownloadContext 执行Post JSON请求并获取响应文件，支持 context 取消及超时。
+// 响应 Content-Type 为 JSON（通常为错误信息）时，文件为 nil，内容以字符串返回，
+// 其他响应（含非 2xx）以流的方式写入临时文件，文件读写位置位于开头，调用方负责关闭及删除
+func HttpPostJsonDownloadContext(ctx context.Context, requestUrl string, json string) (string, *os.File, int, error) {
+	return HttpDefaultClient().postJsonDownload(ctx, "x.HttpPostJsonDownloadContext", requestUrl, json)
+}
+
+// postJsonDownload 执行Post JSON请求并将响应内容以流的方式写入临时文件，
+// 响应内容类型为 JSON 时视为错误信息，以字符串返回，与 HttpPostJsonDownloadStream 不同，不校验响应状态码
+func (c *HttpClient) postJsonDownload(ctx context.Context, op, requestUrl string, json string) (string, *os.File, int, error) {
+	resp, err := c.doContext(ctx, op, http.MethodPost, requestUrl, strings.NewReader(json), httpContentTypeJson, nil)
 	if err != nil {
-		return false, fmt.Sprintf(
-			"[%s] %s", "x.HttpPostJsonDownload", "Network Request Error："+err.Error(),
-		), nil, 0
+		return "", nil, 0, err
 	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(resp.Body)
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false, fmt.Sprintf(
-			"[%s] %s", "x.HttpPostJsonDownload", "Network Response Error："+err.Error(),
-		), nil, resp.StatusCode
-	}
-	if strings.HasPrefix(string(body), "{") && strings.HasSuffix(string(body), "}") {
-		return false, string(body), nil, resp.StatusCode
+	if httpIsJsonContent(resp.Header.Get("Content-Type")) {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", nil, resp.StatusCode, &HttpError{Op: op, Msg: "Network Response Error", Err: err}
+		}
+		return string(body), nil, resp.StatusCode, nil
 	}
 	f, err := os.CreateTemp("", "")
 	if err != nil {
-		return false, fmt.Sprintf(
-			"[%s] %s", "x.HttpPostJsonDownload", "Create temp file Error："+err.Error(),
-		), nil, 0
+		return "", nil, resp.StatusCode, &HttpError{Op: op, Msg: "Create temp file Error", Err: err}
+	}
+	result := &HttpDownloadResult{StatusCode: resp.StatusCode, Total: resp.ContentLength}
+	err = httpCopyDownload(op, f, resp.Body, result, 0, nil)
+	if err == nil {
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			err = &HttpError{Op: op, Msg: "Write temp file Error", Err: err}
+		}
 	}
-	err = os.WriteFile(f.Name(), body, 0644)
 	if err != nil {
-		return false, fmt.Sprintf(
-			"[%s] %s", "x.HttpPostJsonDownload", "Write temp file Error："+err.Error(),
-		), nil, resp.StatusCode
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", nil, resp.StatusCode, err
 	}
-	return true, "", f, resp.StatusCode
+	return "", f, resp.StatusCode, nil
 }
@@ -0,0 +1,434 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpContentTypeForm = "application/x-www-form-urlencoded;charset=utf-8"
+	httpContentTypeJson = "application/json;charset=utf-8"
+	httpContentTypeXml  = "application/xml;charset=utf-8"
+)
+
+const (
+	httpDefaultTimeout     = 30 * time.Second // 默认请求总超时时间
+	httpDefaultDialTimeout = 10 * time.Second // 默认建立连接超时时间
+)
+
+var (
+	ErrHttpTimeout  = errors.New("x: http request timeout")  // 请求超时（含 context 截止时间到达）
+	ErrHttpCanceled = errors.New("x: http request canceled") // 请求被 context 取消
+)
+
+var (
+	httpSharedTransport = newHttpTransport(httpDefaultDialTimeout) // 全局共享连接池
+	httpDefaultClient   = NewHttpClient()                          // x.Http* 系列函数使用的默认客户端
+	httpDefaultMutex    sync.RWMutex
+)
+
+// HttpError HTTP 请求错误，可通过 errors.Is 判断 ErrHttpTimeout、ErrHttpCanceled
+type HttpError struct {
+	Op  string // 操作名称，例：x.HttpGet
+	Msg string // 错误描述，例：Network Request Error
+	Err error  // 原始错误
+}
+
+func (e *HttpError) Error() string {
+	return fmt.Sprintf("[%s] %s：%s", e.Op, e.Msg, e.Err.Error())
+}
+
+func (e *HttpError) Unwrap() error {
+	return e.Err
+}
+
+func (e *HttpError) Is(target error) bool {
+	switch target {
+	case ErrHttpTimeout:
+		if errors.Is(e.Err, context.DeadlineExceeded) {
+			return true
+		}
+		var ne net.Error
+		return errors.As(e.Err, &ne) && ne.Timeout()
+	case ErrHttpCanceled:
+		return errors.Is(e.Err, context.Canceled)
+	}
+	return false
+}
+
+// HttpClient 可复用的 HTTP 客户端，同一实例内共享连接池，可并发使用
+type HttpClient struct {
+	baseURL      *url.URL
+	header       http.Header
+	client       *http.Client
+	retry        *HttpRetryPolicy
+	breaker      *HttpCircuitBreaker
+	interceptors []HttpInterceptor
+}
+
+// httpClientOptions HttpClient 构建参数
+type httpClientOptions struct {
+	baseURL      string
+	header       http.Header
+	timeout      time.Duration
+	dialTimeout  time.Duration
+	proxy        func(*http.Request) (*url.URL, error)
+	tlsConfig    *tls.Config
+	transport    http.RoundTripper
+	retry        *HttpRetryPolicy
+	breaker      *HttpCircuitBreaker
+	interceptors []HttpInterceptor
+	jar          http.CookieJar
+	redirect     func(req *http.Request, via []*http.Request) error
+	err          error // 选项错误，由 NewHttpClientE 返回
+}
+
+// HttpOption HttpClient 构建选项
+type HttpOption func(*httpClientOptions)
+
+// HttpWithBaseURL 设置基础地址，请求地址为相对路径时基于该地址解析
+func HttpWithBaseURL(baseURL string) HttpOption {
+	return func(o *httpClientOptions) {
+		o.baseURL = baseURL
+	}
+}
+
+// HttpWithHeader 设置默认请求头，请求时未指定的请求头使用该值
+func HttpWithHeader(key, value string) HttpOption {
+	return func(o *httpClientOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// HttpWithHeaders 批量设置默认请求头
+func HttpWithHeaders(headers map[string]string) HttpOption {
+	return func(o *httpClientOptions) {
+		for key, val := range headers {
+			o.header.Set(key, val)
+		}
+	}
+}
+
+// HttpWithTimeout 设置请求总超时时间（含连接、发送、读取响应），0 表示不限制
+func HttpWithTimeout(timeout time.Duration) HttpOption {
+	return func(o *httpClientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// HttpWithDialTimeout 设置建立连接超时时间
+func HttpWithDialTimeout(timeout time.Duration) HttpOption {
+	return func(o *httpClientOptions) {
+		o.dialTimeout = timeout
+	}
+}
+
+// HttpWithProxy 设置代理地址，例：http://127.0.0.1:8888，为空时不设置。
+// 地址无效时 NewHttpClientE 返回错误，NewHttpClient 创建的客户端发送请求时返回该错误，不会绕过代理直连
+func HttpWithProxy(proxyUrl string) HttpOption {
+	return func(o *httpClientOptions) {
+		if proxyUrl == "" {
+			return
+		}
+		uri, err := url.Parse(proxyUrl)
+		if err == nil && uri.Host == "" {
+			err = errors.New("missing host")
+		}
+		if err != nil {
+			err = fmt.Errorf("x: invalid http proxy %q: %w", proxyUrl, err)
+			o.proxy = func(*http.Request) (*url.URL, error) {
+				return nil, err
+			}
+			if o.err == nil {
+				o.err = err
+			}
+			return
+		}
+		o.proxy = http.ProxyURL(uri)
+	}
+}
+
+// HttpWithProxyFunc 设置代理选择函数，例：http.ProxyFromEnvironment
+func HttpWithProxyFunc(proxy func(*http.Request) (*url.URL, error)) HttpOption {
+	return func(o *httpClientOptions) {
+		o.proxy = proxy
+	}
+}
+
+// HttpWithTLSConfig 设置 TLS 配置
+func HttpWithTLSConfig(conf *tls.Config) HttpOption {
+	return func(o *httpClientOptions) {
+		o.tlsConfig = conf
+	}
+}
+
+// HttpWithTransport 设置自定义 Transport，设置后代理、TLS、连接超时选项不再生效
+func HttpWithTransport(transport http.RoundTripper) HttpOption {
+	return func(o *httpClientOptions) {
+		o.transport = transport
+	}
+}
+
+// HttpWithCookieJar 设置 Cookie 容器
+func HttpWithCookieJar(jar http.CookieJar) HttpOption {
+	return func(o *httpClientOptions) {
+		o.jar = jar
+	}
+}
+
+// HttpWithRedirect 设置最大重定向次数，0 表示不跟随重定向并直接返回 3xx 响应，默认最多 10 次
+func HttpWithRedirect(maximum int) HttpOption {
+	return func(o *httpClientOptions) {
+		o.redirect = func(req *http.Request, via []*http.Request) error {
+			if maximum <= 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maximum {
+				return fmt.Errorf("stopped after %d redirects", maximum)
+			}
+			return nil
+		}
+	}
+}
+
+// HttpWithRedirectFunc 设置自定义重定向策略，参见 http.Client.CheckRedirect
+func HttpWithRedirectFunc(check func(req *http.Request, via []*http.Request) error) HttpOption {
+	return func(o *httpClientOptions) {
+		o.redirect = check
+	}
+}
+
+// NewHttpClient 创建 HTTP 客户端，未指定代理、TLS 及连接超时时使用全局共享连接池，
+// 忽略选项错误，需校验选项（例：代理地址）时使用 NewHttpClientE
+func NewHttpClient(opts ...HttpOption) *HttpClient {
+	c, _ := NewHttpClientE(opts...)
+	return c
+}
+
+// NewHttpClientE 创建 HTTP 客户端，选项无效（例：基础地址或代理地址无法解析）时返回错误
+func NewHttpClientE(opts ...HttpOption) (*HttpClient, error) {
+	o := &httpClientOptions{
+		header:      make(http.Header),
+		timeout:     httpDefaultTimeout,
+		dialTimeout: httpDefaultDialTimeout,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c := &HttpClient{header: o.header, retry: o.retry, breaker: o.breaker, interceptors: o.interceptors}
+	if o.baseURL != "" {
+		if uri, err := url.Parse(o.baseURL); err == nil {
+			c.baseURL = uri
+		} else if o.err == nil {
+			o.err = fmt.Errorf("x: invalid http base url %q: %w", o.baseURL, err)
+		}
+	}
+	transport := o.transport
+	if transport == nil {
+		if o.proxy == nil && o.tlsConfig == nil && o.dialTimeout == httpDefaultDialTimeout {
+			transport = httpSharedTransport
+		} else {
+			t := newHttpTransport(o.dialTimeout)
+			if o.proxy != nil {
+				t.Proxy = o.proxy
+			}
+			if o.tlsConfig != nil {
+				t.TLSClientConfig = o.tlsConfig
+			}
+			transport = t
+		}
+	}
+	c.client = &http.Client{Transport: transport, Timeout: o.timeout, Jar: o.jar, CheckRedirect: o.redirect}
+	return c, o.err
+}
+
+// HttpDefaultClient 获取 x.Http* 系列函数使用的默认客户端
+func HttpDefaultClient() *HttpClient {
+	httpDefaultMutex.RLock()
+	defer httpDefaultMutex.RUnlock()
+	return httpDefaultClient
+}
+
+// HttpSetDefaultClient 设置 x.Http* 系列函数使用的默认客户端
+func HttpSetDefaultClient(c *HttpClient) {
+	if c == nil {
+		return
+	}
+	httpDefaultMutex.Lock()
+	defer httpDefaultMutex.Unlock()
+	httpDefaultClient = c
+}
+
+// Client 获取底层 http.Client
+func (c *HttpClient) Client() *http.Client {
+	return c.client
+}
+
+// CloseIdleConnections 关闭空闲连接，客户端使用全局共享连接池时（未指定代理、TLS、连接超时及 Transport）
+// 将关闭所有共享该连接池的客户端（含 x.Http* 系列函数）的空闲连接
+func (c *HttpClient) CloseIdleConnections() {
+	c.client.CloseIdleConnections()
+}
+
+// NewRequest 创建请求，解析基础地址并填充默认请求头
+func (c *HttpClient) NewRequest(method, requestUrl string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, c.resolve(requestUrl), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, vals := range c.header {
+		req.Header[key] = append([]string(nil), vals...)
+	}
+	return req, nil
+}
+
+// Do 发送请求，按客户端配置执行拦截器、重试及熔断，调用方负责关闭响应体
+func (c *HttpClient) Do(req *http.Request) (*http.Response, error) {
+	if c.retry == nil && c.breaker == nil {
+		return c.intercept(req)
+	}
+	return c.doRetry(req)
+}
+
+// Send 执行请求并读取响应内容，headers 覆盖默认请求头
+func (c *HttpClient) Send(method, requestUrl string, body io.Reader, headers map[string]string) (bool, string, int) {
+	return c.send("x.HttpClient.Send", method, requestUrl, body, "", headers)
+}
+
+// SendContext 执行请求并读取响应内容，headers 覆盖默认请求头
+func (c *HttpClient) SendContext(ctx context.Context, method, requestUrl string, body io.Reader, headers map[string]string) (string, int, error) {
+	return c.sendContext(ctx, "x.HttpClient.SendContext", method, requestUrl, body, "", headers)
+}
+
+// Get 执行Get请求
+func (c *HttpClient) Get(requestUrl string) (bool, string, int) {
+	return c.send("x.HttpClient.Get", http.MethodGet, requestUrl, nil, "", nil)
+}
+
+// GetContext 执行Get请求
+func (c *HttpClient) GetContext(ctx context.Context, requestUrl string) (string, int, error) {
+	return c.sendContext(ctx, "x.HttpClient.GetContext", http.MethodGet, requestUrl, nil, "", nil)
+}
+
+// PostForm 执行Post Form 请求
+func (c *HttpClient) PostForm(requestUrl string, data url.Values) (bool, string, int) {
+	return c.send("x.HttpClient.PostForm", http.MethodPost, requestUrl, strings.NewReader(data.Encode()),
+		httpContentTypeForm, nil)
+}
+
+// PostFormContext 执行Post Form 请求
+func (c *HttpClient) PostFormContext(ctx context.Context, requestUrl string, data url.Values) (string, int, error) {
+	return c.sendContext(ctx, "x.HttpClient.PostFormContext", http.MethodPost, requestUrl, strings.NewReader(data.Encode()),
+		httpContentTypeForm, nil)
+}
+
+// PostJson 执行Post JSON请求
+func (c *HttpClient) PostJson(requestUrl string, json string, headers map[string]string) (bool, string, int) {
+	return c.send("x.HttpClient.PostJson", http.MethodPost, requestUrl, strings.NewReader(json),
+		httpContentTypeJson, headers)
+}
+
+// PostJsonContext 执行Post JSON请求
+func (c *HttpClient) PostJsonContext(ctx context.Context, requestUrl string, json string, headers map[string]string) (string, int, error) {
+	return c.sendContext(ctx, "x.HttpClient.PostJsonContext", http.MethodPost, requestUrl, strings.NewReader(json),
+		httpContentTypeJson, headers)
+}
+
+// PostXml 执行Post XML请求
+func (c *HttpClient) PostXml(requestUrl string, xml string, headers map[string]string) (bool, string, int) {
+	return c.send("x.HttpClient.PostXml", http.MethodPost, requestUrl, strings.NewReader(xml),
+		httpContentTypeXml, headers)
+}
+
+// PostXmlContext 执行Post XML请求
+func (c *HttpClient) PostXmlContext(ctx context.Context, requestUrl string, xml string, headers map[string]string) (string, int, error) {
+	return c.sendContext(ctx, "x.HttpClient.PostXmlContext", http.MethodPost, requestUrl, strings.NewReader(xml),
+		httpContentTypeXml, headers)
+}
+
+// send 执行请求，错误信息以字符串形式返回
+func (c *HttpClient) send(op, method, requestUrl string, body io.Reader, contentType string, headers map[string]string) (bool, string, int) {
+	data, code, err := c.sendContext(context.Background(), op, method, requestUrl, body, contentType, headers)
+	if err != nil {
+		return false, err.Error(), code
+	}
+	return true, data, code
+}
+
+// sendContext 执行请求，op 用于错误信息前缀
+func (c *HttpClient) sendContext(ctx context.Context, op, method, requestUrl string, body io.Reader, contentType string, headers map[string]string) (string, int, error) {
+	r := c.execute(ctx, op, method, requestUrl, body, contentType, headers)
+	if r.Err != nil {
+		return "", r.StatusCode, r.Err
+	}
+	return string(r.Body), r.StatusCode, nil
+}
+
+// doContext 创建并发送请求，调用方负责关闭响应体
+func (c *HttpClient) doContext(ctx context.Context, op, method, requestUrl string, body io.Reader, contentType string, headers map[string]string) (*http.Response, error) {
+	req, err := c.NewRequest(method, requestUrl, body)
+	if err != nil {
+		return nil, &HttpError{Op: op, Msg: "Network Create Error", Err: err}
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for key, val := range headers {
+		req.Header.Set(key, val)
+	}
+	resp, err := c.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, &HttpError{Op: op, Msg: "Network Request Error", Err: err}
+	}
+	return resp, nil
+}
+
+// resolve 基于基础地址解析请求地址
+func (c *HttpClient) resolve(requestUrl string) string {
+	if c.baseURL == nil {
+		return requestUrl
+	}
+	ref, err := url.Parse(requestUrl)
+	if err != nil || ref.IsAbs() {
+		return requestUrl
+	}
+	base := *c.baseURL
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	ref.Path = strings.TrimPrefix(ref.Path, "/")
+	return base.ResolveReference(ref).String()
+}
+
+// newHttpTransport 创建连接池
+func newHttpTransport(dialTimeout time.Duration) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
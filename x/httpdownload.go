@@ -0,0 +1,296 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HttpDownloadOptions 下载选项
+type HttpDownloadOptions struct {
+	Method    string                     // 请求方法，为空时：Body 为空使用 GET，否则使用 POST
+	Body      []byte                     // 请求内容
+	Header    map[string]string          // 请求头
+	Progress  func(written, total int64) // 进度回调，written 为已写入字节数（含续传前已有部分），total 未知时为 -1
+	Resume    bool                       // 是否断点续传，仅 DownloadFile 有效，目标文件已存在时使用 Range 请求剩余部分，filePath 须为文件路径
+	AllowJson bool                       // 是否允许 JSON 响应，默认 JSON 响应视为错误信息，返回 *HttpContentTypeError
+}
+
+// HttpDownloadResult 下载结果
+type HttpDownloadResult struct {
+	StatusCode int         // 响应状态码
+	Header     http.Header // 响应头
+	FileName   string      // Content-Disposition 中的文件名，未提供时为空
+	Path       string      // 文件保存路径，仅 DownloadFile 有效
+	Written    int64       // 本次写入字节数
+	Total      int64       // 文件总大小，未知时为 -1
+	Resumed    bool        // 是否为断点续传
+}
+
+// ErrHttpResumeDir 断点续传时 filePath 为目录，无法在请求前确定目标文件
+var ErrHttpResumeDir = errors.New("x: http download resume requires a file path")
+
+// HttpContentTypeError 下载时响应内容类型为错误信息（JSON）时返回的错误，携带原始响应内容
+type HttpContentTypeError struct {
+	StatusCode  int    // 响应状态码
+	ContentType string // 响应内容类型
+	Body        []byte // 原始响应内容
+}
+
+func (e *HttpContentTypeError) Error() string {
+	return fmt.Sprintf("x: http download got unexpected content type %s, status %d", e.ContentType, e.StatusCode)
+}
+
+// HttpParseContentDisposition 解析 Content-Disposition 响应头中的文件名，支持 filename* 编码格式
+func HttpParseContentDisposition(value string) string {
+	if value == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(value)
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+// HttpDownload 使用默认客户端下载内容并以流的方式写入 w
+func HttpDownload(ctx context.Context, requestUrl string, w io.Writer, opts *HttpDownloadOptions) (*HttpDownloadResult, error) {
+	return HttpDefaultClient().download(ctx, "x.HttpDownload", requestUrl, w, opts)
+}
+
+// HttpDownloadFile 使用默认客户端下载文件，filePath 为目录时使用 Content-Disposition 或请求地址中的文件名，
+// 此时不支持断点续传，opts.Resume 为 true 时返回 ErrHttpResumeDir
+func HttpDownloadFile(ctx context.Context, requestUrl string, filePath string, opts *HttpDownloadOptions) (*HttpDownloadResult, error) {
+	return HttpDefaultClient().downloadFile(ctx, "x.HttpDownloadFile", requestUrl, filePath, opts)
+}
+
+// HttpPostJsonDownloadStream 使用默认客户端执行Post JSON请求并将响应内容以流的方式写入 w，
+// 非 2xx 响应返回 *HttpStatusError，JSON 响应返回 *HttpContentTypeError，均携带原始响应内容
+func HttpPostJsonDownloadStream(ctx context.Context, requestUrl string, json string, w io.Writer) (*HttpDownloadResult, error) {
+	return HttpDefaultClient().postJsonDownloadStream(ctx, "x.HttpPostJsonDownloadStream", requestUrl, json, w)
+}
+
+// Download 下载内容并以流的方式写入 w
+func (c *HttpClient) Download(ctx context.Context, requestUrl string, w io.Writer, opts *HttpDownloadOptions) (*HttpDownloadResult, error) {
+	return c.download(ctx, "x.HttpClient.Download", requestUrl, w, opts)
+}
+
+// DownloadFile 下载文件，filePath 为目录时使用 Content-Disposition 或请求地址中的文件名，
+// 此时不支持断点续传，opts.Resume 为 true 时返回 ErrHttpResumeDir
+func (c *HttpClient) DownloadFile(ctx context.Context, requestUrl string, filePath string, opts *HttpDownloadOptions) (*HttpDownloadResult, error) {
+	return c.downloadFile(ctx, "x.HttpClient.DownloadFile", requestUrl, filePath, opts)
+}
+
+// PostJsonDownloadStream 执行Post JSON请求并将响应内容以流的方式写入 w，错误判断同 HttpPostJsonDownloadStream
+func (c *HttpClient) PostJsonDownloadStream(ctx context.Context, requestUrl string, json string, w io.Writer) (*HttpDownloadResult, error) {
+	return c.postJsonDownloadStream(ctx, "x.HttpClient.PostJsonDownloadStream", requestUrl, json, w)
+}
+
+// postJsonDownloadStream 执行Post JSON请求并将响应内容写入 w
+func (c *HttpClient) postJsonDownloadStream(ctx context.Context, op, requestUrl string, json string, w io.Writer) (*HttpDownloadResult, error) {
+	return c.download(ctx, op, requestUrl, w, &HttpDownloadOptions{
+		Method: http.MethodPost,
+		Body:   []byte(json),
+		Header: map[string]string{"Content-Type": httpContentTypeJson},
+	})
+}
+
+// download 下载内容写入 w
+func (c *HttpClient) download(ctx context.Context, op, requestUrl string, w io.Writer, opts *HttpDownloadOptions) (*HttpDownloadResult, error) {
+	if opts == nil {
+		opts = &HttpDownloadOptions{}
+	}
+	resp, result, err := c.openDownload(ctx, op, requestUrl, opts, 0)
+	if err != nil {
+		return result, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	return result, httpCopyDownload(op, w, resp.Body, result, 0, opts.Progress)
+}
+
+// downloadFile 下载文件，支持断点续传
+func (c *HttpClient) downloadFile(ctx context.Context, op, requestUrl string, filePath string, opts *HttpDownloadOptions) (*HttpDownloadResult, error) {
+	if opts == nil {
+		opts = &HttpDownloadOptions{}
+	}
+	var offset int64
+	isDir := false
+	if info, err := os.Stat(filePath); err == nil {
+		if info.IsDir() {
+			if opts.Resume {
+				return &HttpDownloadResult{Total: -1}, &HttpError{Op: op, Msg: "Open file Error",
+					Err: fmt.Errorf("%w: %s is a directory", ErrHttpResumeDir, filePath)}
+			}
+			isDir = true
+		} else if opts.Resume {
+			offset = info.Size()
+		}
+	}
+	resp, result, err := c.openDownload(ctx, op, requestUrl, opts, offset)
+	if err != nil {
+		var se *HttpStatusError
+		if offset > 0 && errors.As(err, &se) && se.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			// 文件已下载完成
+			if total := httpContentRangeTotal(se.Header.Get("Content-Range")); total == offset {
+				result.Path, result.Total, result.Resumed = filePath, total, true
+				return result, nil
+			}
+		}
+		return result, err
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	if isDir {
+		name := result.FileName
+		if name == "" && resp.Request != nil {
+			name = path.Base(resp.Request.URL.Path)
+		}
+		name = filepath.Base(filepath.Clean(string(filepath.Separator) + name))
+		if name == "" || name == "." || name == string(filepath.Separator) {
+			name = "download"
+		}
+		filePath = filepath.Join(filePath, name)
+	}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resp.StatusCode == http.StatusPartialContent && offset > 0 {
+		flag = os.O_WRONLY | os.O_APPEND
+		result.Resumed = true
+	} else {
+		offset = 0
+	}
+	f, err := os.OpenFile(filePath, flag, 0644)
+	if err != nil {
+		return result, &HttpError{Op: op, Msg: "Open file Error", Err: err}
+	}
+	result.Path = filePath
+	if err = httpCopyDownload(op, f, resp.Body, result, offset, opts.Progress); err != nil {
+		_ = f.Close()
+		return result, err
+	}
+	if err = f.Close(); err != nil {
+		return result, &HttpError{Op: op, Msg: "Write file Error", Err: err}
+	}
+	return result, nil
+}
+
+// openDownload 发送下载请求并校验响应，offset 大于 0 时使用 Range 请求
+func (c *HttpClient) openDownload(ctx context.Context, op, requestUrl string, opts *HttpDownloadOptions, offset int64) (*http.Response, *HttpDownloadResult, error) {
+	result := &HttpDownloadResult{Total: -1}
+	method := opts.Method
+	var body io.Reader
+	if opts.Body != nil {
+		body = bytes.NewReader(opts.Body)
+		if method == "" {
+			method = http.MethodPost
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	headers := make(map[string]string, len(opts.Header)+1)
+	for key, val := range opts.Header {
+		headers[key] = val
+	}
+	if offset > 0 {
+		headers["Range"] = "bytes=" + strconv.FormatInt(offset, 10) + "-"
+	}
+	resp, err := c.doContext(ctx, op, method, requestUrl, body, "", headers)
+	if err != nil {
+		return nil, result, err
+	}
+	result.StatusCode = resp.StatusCode
+	result.Header = resp.Header
+	result.FileName = HttpParseContentDisposition(resp.Header.Get("Content-Disposition"))
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isJson := httpIsJsonContent(mediaType)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || (isJson && !opts.AllowJson) {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, result, &HttpStatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}
+		}
+		return nil, result, &HttpContentTypeError{StatusCode: resp.StatusCode, ContentType: mediaType, Body: data}
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		if start := httpContentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			_ = resp.Body.Close()
+			return nil, result, &HttpError{Op: op, Msg: "Network Response Error",
+				Err: fmt.Errorf("unexpected content range %q", resp.Header.Get("Content-Range"))}
+		}
+		result.Total = httpContentRangeTotal(resp.Header.Get("Content-Range"))
+	} else if resp.ContentLength >= 0 {
+		result.Total = resp.ContentLength
+	}
+	return resp, result, nil
+}
+
+// httpIsJsonContent 响应内容类型是否为 JSON，例：application/json、application/problem+json
+func httpIsJsonContent(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// httpCopyDownload 将响应内容写入 w 并回调进度
+func httpCopyDownload(op string, w io.Writer, r io.Reader, result *HttpDownloadResult, offset int64, progress func(written, total int64)) error {
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return &HttpError{Op: op, Msg: "Write file Error", Err: werr}
+			}
+			result.Written += int64(n)
+			if progress != nil {
+				progress(offset+result.Written, result.Total)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return &HttpError{Op: op, Msg: "Network Response Error", Err: err}
+		}
+	}
+}
+
+// httpContentRangeStart 解析 Content-Range 起始位置，例：bytes 100-199/200
+func httpContentRangeStart(value string) int64 {
+	value = strings.TrimPrefix(value, "bytes ")
+	if idx := strings.IndexByte(value, '-'); idx > 0 {
+		if start, err := strconv.ParseInt(value[:idx], 10, 64); err == nil {
+			return start
+		}
+	}
+	return -1
+}
+
+// httpContentRangeTotal 解析 Content-Range 总大小，例：bytes 100-199/200、bytes */200
+func httpContentRangeTotal(value string) int64 {
+	if idx := strings.LastIndexByte(value, '/'); idx >= 0 {
+		if total, err := strconv.ParseInt(value[idx+1:], 10, 64); err == nil {
+			return total
+		}
+	}
+	return -1
+}
@@ -0,0 +1,186 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	gbox "github.com/mvity/go-box"
+)
+
+// HttpInterceptor 请求拦截器，每次实际发送请求（含重试）前后调用。
+// 多个拦截器按注册顺序执行 BeforeRequest，按相反顺序执行 AfterResponse
+type HttpInterceptor interface {
+	// BeforeRequest 请求发送前调用，可修改请求头或返回携带新 context 的请求，返回错误时终止请求
+	BeforeRequest(req *http.Request) (*http.Request, error)
+	// AfterResponse 收到响应或请求出错后调用，返回错误时替换原结果并关闭响应体。
+	// 不处理错误时须返回传入的 err 以透传，请求出错（resp 为 nil）时返回 nil 不会清除原错误
+	AfterResponse(req *http.Request, resp *http.Response, err error) error
+}
+
+// HttpInterceptorFunc 以函数形式实现 HttpInterceptor，未设置的函数不执行
+type HttpInterceptorFunc struct {
+	Before func(req *http.Request) (*http.Request, error)
+	After  func(req *http.Request, resp *http.Response, err error) error
+}
+
+func (f HttpInterceptorFunc) BeforeRequest(req *http.Request) (*http.Request, error) {
+	if f.Before == nil {
+		return req, nil
+	}
+	return f.Before(req)
+}
+
+func (f HttpInterceptorFunc) AfterResponse(req *http.Request, resp *http.Response, err error) error {
+	if f.After == nil {
+		return err
+	}
+	return f.After(req, resp, err)
+}
+
+// HttpWithInterceptors 追加请求拦截器
+func HttpWithInterceptors(interceptors ...HttpInterceptor) HttpOption {
+	return func(o *httpClientOptions) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// intercept 执行拦截器并发送单次请求
+func (c *HttpClient) intercept(req *http.Request) (*http.Response, error) {
+	var err error
+	done := 0
+	for _, i := range c.interceptors {
+		var next *http.Request
+		if next, err = i.BeforeRequest(req); err != nil {
+			break
+		}
+		if next != nil {
+			req = next
+		}
+		done++
+	}
+	var resp *http.Response
+	if err == nil {
+		resp, err = c.client.Do(req)
+	}
+	for idx := done - 1; idx >= 0; idx-- {
+		next := c.interceptors[idx].AfterResponse(req, resp, err)
+		if next == nil && resp == nil {
+			// 没有响应时保留原错误，避免返回 (nil, nil)
+			continue
+		}
+		err = next
+		if err != nil && resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+			resp = nil
+		}
+	}
+	return resp, err
+}
+
+type httpContextKey int
+
+const (
+	httpRequestIDKey httpContextKey = iota // 请求ID
+	httpStartTimeKey                       // 请求开始时间
+)
+
+// HttpContextWithRequestID 在 context 中携带请求ID，供 HttpRequestIDInterceptor 透传
+func HttpContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, httpRequestIDKey, requestID)
+}
+
+// HttpRequestIDFromContext 获取 context 中携带的请求ID
+func HttpRequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(httpRequestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+// HttpRequestIDInterceptor 请求ID透传拦截器，header 为空时使用 X-Request-ID。
+// 请求头已存在时保持不变，否则优先使用 context 中的请求ID，均不存在时随机生成
+func HttpRequestIDInterceptor(header string) HttpInterceptor {
+	if header == "" {
+		header = "X-Request-ID"
+	}
+	return HttpInterceptorFunc{Before: func(req *http.Request) (*http.Request, error) {
+		if req.Header.Get(header) != "" {
+			return req, nil
+		}
+		id := HttpRequestIDFromContext(req.Context())
+		if id == "" {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		req.Header.Set(header, id)
+		return req, nil
+	}}
+}
+
+// HttpBearerTokenInterceptor 认证令牌拦截器，每次请求前通过 source 获取令牌并设置 Authorization 请求头，
+// 令牌缓存及刷新由 source 负责
+func HttpBearerTokenInterceptor(source func(ctx context.Context) (string, error)) HttpInterceptor {
+	return HttpInterceptorFunc{Before: func(req *http.Request) (*http.Request, error) {
+		token, err := source(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	}}
+}
+
+// HttpMetricsInterceptor 请求指标拦截器，每次请求结束后回调，status 为 0 表示请求未完成
+func HttpMetricsInterceptor(observe func(req *http.Request, status int, elapsed time.Duration, err error)) HttpInterceptor {
+	return HttpInterceptorFunc{
+		Before: httpMarkStart,
+		After: func(req *http.Request, resp *http.Response, err error) error {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			observe(req, status, httpElapsed(req), err)
+			return err
+		},
+	}
+}
+
+// HttpLogInterceptor 请求日志拦截器，logf 为空时使用 gbox.WARN 输出（仅 DEBUG 模式有效）
+func HttpLogInterceptor(logf func(format string, v ...any)) HttpInterceptor {
+	if logf == nil {
+		logf = gbox.WARN
+	}
+	return HttpMetricsInterceptor(func(req *http.Request, status int, elapsed time.Duration, err error) {
+		if err != nil {
+			logf("[x.HttpClient] %s %s error: %v, elapsed: %v", req.Method, req.URL.String(), err, elapsed)
+		} else {
+			logf("[x.HttpClient] %s %s status: %d, elapsed: %v", req.Method, req.URL.String(), status, elapsed)
+		}
+	})
+}
+
+// httpMarkStart 在请求 context 中记录开始时间
+func httpMarkStart(req *http.Request) (*http.Request, error) {
+	return req.WithContext(context.WithValue(req.Context(), httpStartTimeKey, time.Now())), nil
+}
+
+// httpElapsed 计算请求耗时
+func httpElapsed(req *http.Request) time.Duration {
+	if start, ok := req.Context().Value(httpStartTimeKey).(time.Time); ok {
+		return time.Since(start)
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// interceptorTestDeadURL 返回已关闭服务的地址，请求该地址将产生网络错误
+func interceptorTestDeadURL() string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestHttpInterceptorOrder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("X-Trace"))
+	}))
+	defer srv.Close()
+	var calls []string
+	hook := func(name string) HttpInterceptor {
+		return HttpInterceptorFunc{
+			Before: func(req *http.Request) (*http.Request, error) {
+				calls = append(calls, "before "+name)
+				req.Header.Set("X-Trace", req.Header.Get("X-Trace")+name)
+				return req, nil
+			},
+			After: func(req *http.Request, resp *http.Response, err error) error {
+				calls = append(calls, "after "+name)
+				return err
+			},
+		}
+	}
+	c := NewHttpClient(HttpWithInterceptors(hook("a"), hook("b")))
+	body, code, err := c.GetContext(context.Background(), srv.URL)
+	if err != nil || code != http.StatusOK || body != "ab" {
+		t.Fatalf("GetContext = %q, %d, %v", body, code, err)
+	}
+	if got := strings.Join(calls, ","); got != "before a,before b,after b,after a" {
+		t.Fatalf("calls = %s", got)
+	}
+}
+
+func TestHttpInterceptorReplaceResult(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+	errTeapot := errors.New("teapot")
+	c := NewHttpClient(HttpWithInterceptors(HttpInterceptorFunc{
+		After: func(req *http.Request, resp *http.Response, err error) error {
+			if resp != nil && resp.StatusCode == http.StatusTeapot {
+				return errTeapot
+			}
+			return err
+		},
+	}))
+	req, _ := c.NewRequest(http.MethodGet, srv.URL, nil)
+	resp, err := c.Do(req)
+	if resp != nil || !errors.Is(err, errTeapot) {
+		t.Fatalf("Do = %v, %v", resp, err)
+	}
+
+	errStop := errors.New("stop")
+	c = NewHttpClient(HttpWithInterceptors(HttpInterceptorFunc{
+		Before: func(req *http.Request) (*http.Request, error) {
+			return nil, errStop
+		},
+	}))
+	if _, _, err = c.GetContext(context.Background(), srv.URL); !errors.Is(err, errStop) {
+		t.Fatalf("BeforeRequest error = %v", err)
+	}
+}
+
+// AfterResponse 在请求出错后返回 nil 时应保留原错误，不能返回 (nil, nil)
+func TestHttpInterceptorKeepsTransportError(t *testing.T) {
+	swallow := HttpInterceptorFunc{
+		After: func(req *http.Request, resp *http.Response, err error) error {
+			return nil
+		},
+	}
+	deadURL := interceptorTestDeadURL()
+	clients := map[string]*HttpClient{
+		"plain":   NewHttpClient(HttpWithInterceptors(swallow)),
+		"retry":   NewHttpClient(HttpWithInterceptors(swallow), HttpWithRetry(HttpRetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond})),
+		"breaker": NewHttpClient(HttpWithInterceptors(swallow), HttpWithCircuitBreaker(NewHttpCircuitBreaker(5, time.Second))),
+	}
+	for name, c := range clients {
+		req, _ := c.NewRequest(http.MethodGet, deadURL, nil)
+		if resp, err := c.Do(req); resp != nil || err == nil {
+			t.Fatalf("%s: Do = %v, %v", name, resp, err)
+		}
+		if _, _, err := c.GetContext(context.Background(), deadURL); err == nil {
+			t.Fatalf("%s: GetContext succeeded", name)
+		}
+		if ok, _, _ := c.Get(deadURL); ok {
+			t.Fatalf("%s: Get succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// HttpJsonError JSON 请求错误，携带响应状态码及原始响应内容，便于记录日志
+type HttpJsonError struct {
+	Op         string // 操作名称，例：x.HttpJsonPost
+	StatusCode int    // 响应状态码，请求未完成时为 0
+	Body       []byte // 原始响应内容
+	Err        error  // 原始错误：*HttpError、*HttpStatusError 或 JSON 编解码错误
+}
+
+func (e *HttpJsonError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("[%s] %s", e.Op, e.Err.Error())
+	}
+	return fmt.Sprintf("[%s] status %d: %s", e.Op, e.StatusCode, e.Err.Error())
+}
+
+func (e *HttpJsonError) Unwrap() error {
+	return e.Err
+}
+
+// HttpJsonGet 执行 Get 请求并将 JSON 响应解析为 Resp 类型，c 为 nil 时使用默认客户端
+func HttpJsonGet[Resp any](ctx context.Context, c *HttpClient, requestUrl string, headers map[string]string) (Resp, error) {
+	return httpJsonDo[Resp](ctx, c, "x.HttpJsonGet", http.MethodGet, requestUrl, nil, headers)
+}
+
+// HttpJsonPost 将 Req 编码为 JSON 执行 Post 请求，并将 JSON 响应解析为 Resp 类型，c 为 nil 时使用默认客户端
+func HttpJsonPost[Req, Resp any](ctx context.Context, c *HttpClient, requestUrl string, body Req, headers map[string]string) (Resp, error) {
+	return httpJsonSend[Req, Resp](ctx, c, "x.HttpJsonPost", http.MethodPost, requestUrl, body, headers)
+}
+
+// HttpJsonPut 将 Req 编码为 JSON 执行 Put 请求，并将 JSON 响应解析为 Resp 类型，c 为 nil 时使用默认客户端
+func HttpJsonPut[Req, Resp any](ctx context.Context, c *HttpClient, requestUrl string, body Req, headers map[string]string) (Resp, error) {
+	return httpJsonSend[Req, Resp](ctx, c, "x.HttpJsonPut", http.MethodPut, requestUrl, body, headers)
+}
+
+// HttpJsonDo 将 Req 编码为 JSON 执行指定方法的请求，并将 JSON 响应解析为 Resp 类型，c 为 nil 时使用默认客户端。
+// 响应状态码非 2xx 时返回 *HttpJsonError，其 Err 为 *HttpStatusError
+func HttpJsonDo[Req, Resp any](ctx context.Context, c *HttpClient, method, requestUrl string, body Req, headers map[string]string) (Resp, error) {
+	return httpJsonSend[Req, Resp](ctx, c, "x.HttpJsonDo", method, requestUrl, body, headers)
+}
+
+// httpJsonSend 将请求内容编码为 JSON 并执行请求
+func httpJsonSend[Req, Resp any](ctx context.Context, c *HttpClient, op, method, requestUrl string, body Req, headers map[string]string) (Resp, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		var zero Resp
+		return zero, &HttpJsonError{Op: op, Err: err}
+	}
+	return httpJsonDo[Resp](ctx, c, op, method, requestUrl, data, headers)
+}
+
+// httpJsonDo 执行请求并解析 JSON 响应，Resp 为 *JsonNode 时解析为 JsonNode
+func httpJsonDo[Resp any](ctx context.Context, c *HttpClient, op, method, requestUrl string, body []byte, headers map[string]string) (Resp, error) {
+	var result Resp
+	if c == nil {
+		c = HttpDefaultClient()
+	}
+	h := map[string]string{"Accept": "application/json"}
+	for key, val := range headers {
+		h[key] = val
+	}
+	contentType := ""
+	var r *HttpResponse
+	if body != nil {
+		contentType = httpContentTypeJson
+		r = c.execute(ctx, op, method, requestUrl, bytes.NewReader(body), contentType, h)
+	} else {
+		r = c.execute(ctx, op, method, requestUrl, nil, contentType, h)
+	}
+	if err := r.Error(); err != nil {
+		return result, &HttpJsonError{Op: op, StatusCode: r.StatusCode, Body: r.Body, Err: err}
+	}
+	if len(bytes.TrimSpace(r.Body)) == 0 {
+		return result, nil
+	}
+	if node, ok := any(&result).(**JsonNode); ok {
+		n, err := JsonFromStringE(string(r.Body))
+		if err != nil {
+			return result, &HttpJsonError{Op: op, StatusCode: r.StatusCode, Body: r.Body, Err: err}
+		}
+		*node = n
+		return result, nil
+	}
+	if err := json.Unmarshal(r.Body, &result); err != nil {
+		return result, &HttpJsonError{Op: op, StatusCode: r.StatusCode, Body: r.Body, Err: err}
+	}
+	return result, nil
+}
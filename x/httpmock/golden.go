@@ -0,0 +1,174 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package httpmock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	gbox "github.com/mvity/go-box"
+)
+
+// GoldenRecordEnv 设置该环境变量（非空）时 NewGolden 以录制模式运行，否则以回放模式运行
+const GoldenRecordEnv = "HTTPMOCK_RECORD"
+
+// Exchange 录制的一次请求及响应，请求头不录制以避免泄露认证信息
+type Exchange struct {
+	Method         string              `json:"method"`
+	Path           string              `json:"path"`
+	Query          string              `json:"query,omitempty"`
+	RequestBody    string              `json:"requestBody,omitempty"`
+	Status         int                 `json:"status"`
+	ResponseHeader map[string][]string `json:"responseHeader,omitempty"`
+	ResponseBody   string              `json:"responseBody,omitempty"`
+	Base64         bool                `json:"base64,omitempty"` // 请求及响应内容是否为 Base64 编码（非 UTF-8 内容）
+}
+
+// NewRecorder 创建录制服务，请求转发至 target 并记录交互，Close 时写入 golden 文件
+func NewRecorder(target, goldenFile string) *Server {
+	s := NewServer()
+	rec := &recorder{target: strings.TrimSuffix(target, "/"), file: goldenFile, client: &http.Client{}}
+	s.fallback = rec
+	s.onClose = func() {
+		if err := rec.save(); err != nil {
+			gbox.WARN("httpmock: save golden file %s failed: %v", goldenFile, err)
+		}
+	}
+	return s
+}
+
+// NewReplayer 从 golden 文件创建回放服务，每条交互按录制顺序匹配一次
+func NewReplayer(goldenFile string) (*Server, error) {
+	data, err := os.ReadFile(goldenFile)
+	if err != nil {
+		return nil, err
+	}
+	var exchanges []Exchange
+	if err = json.Unmarshal(data, &exchanges); err != nil {
+		return nil, err
+	}
+	s := NewServer()
+	for _, e := range exchanges {
+		reqBody, respBody := []byte(e.RequestBody), []byte(e.ResponseBody)
+		if e.Base64 {
+			reqBody, _ = base64.StdEncoding.DecodeString(e.RequestBody)
+			respBody, _ = base64.StdEncoding.DecodeString(e.ResponseBody)
+		}
+		r := s.On(e.Method, e.Path).Once().WithBodyFunc(func(body []byte) bool {
+			return jsonOrBytesEqual(reqBody, body)
+		})
+		exact, _ := url.ParseQuery(e.Query)
+		r.set(func() {
+			r.exact = exact
+			r.respHead = http.Header(e.ResponseHeader).Clone()
+			r.status, r.respBody = e.Status, respBody
+		})
+	}
+	return s, nil
+}
+
+// NewGolden 根据环境变量 HTTPMOCK_RECORD 选择模式：设置时转发至 target 并录制到 golden 文件，否则从 golden 文件回放
+func NewGolden(target, goldenFile string) (*Server, error) {
+	if os.Getenv(GoldenRecordEnv) != "" {
+		return NewRecorder(target, goldenFile), nil
+	}
+	return NewReplayer(goldenFile)
+}
+
+// recorder 请求转发及录制
+type recorder struct {
+	target    string
+	file      string
+	client    *http.Client
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, rec.target+req.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	out.Header = req.Header.Clone()
+	out.Header.Del("Accept-Encoding")
+	resp, err := rec.client.Do(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	respBody, _ := io.ReadAll(resp.Body)
+
+	e := Exchange{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery, Status: resp.StatusCode,
+		ResponseHeader: map[string][]string{}}
+	for key, vals := range resp.Header {
+		if key != "Date" && key != "Content-Length" {
+			e.ResponseHeader[key] = vals
+		}
+	}
+	if utf8.Valid(body) && utf8.Valid(respBody) {
+		e.RequestBody, e.ResponseBody = string(body), string(respBody)
+	} else {
+		e.RequestBody = base64.StdEncoding.EncodeToString(body)
+		e.ResponseBody = base64.StdEncoding.EncodeToString(respBody)
+		e.Base64 = true
+	}
+	rec.mu.Lock()
+	rec.exchanges = append(rec.exchanges, e)
+	rec.mu.Unlock()
+
+	for key, vals := range resp.Header {
+		if key != "Content-Length" {
+			w.Header()[key] = vals
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(respBody)
+}
+
+// save 写入 golden 文件
+func (rec *recorder) save() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	data, err := json.MarshalIndent(rec.exchanges, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(rec.file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(rec.file, data, 0644)
+}
+
+// jsonOrBytesEqual 比较录制的请求内容与实际请求内容，均为 JSON 时忽略字段顺序及空白
+func jsonOrBytesEqual(expected, actual []byte) bool {
+	if bytes.Equal(expected, actual) {
+		return true
+	}
+	e, err1 := jsonNormalize(expected)
+	a, err2 := jsonNormalize(actual)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	ed, _ := json.Marshal(e)
+	ad, _ := json.Marshal(a)
+	return bytes.Equal(ed, ad)
+}
@@ -0,0 +1,489 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+// Package httpmock 提供用于测试 x.Http* 调用代码的 HTTP 模拟服务，支持路由匹配、预设响应、调用断言及录制回放
+package httpmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mvity/go-box/x"
+)
+
+// TestingT 断言使用的测试接口，*testing.T 与 *testing.B 均已实现
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Call 模拟服务收到的请求记录
+type Call struct {
+	Method string      // 请求方法
+	Path   string      // 请求路径
+	Query  url.Values  // 查询参数
+	Header http.Header // 请求头
+	Body   []byte      // 请求内容
+	Time   time.Time   // 请求时间
+}
+
+// Server HTTP 模拟服务
+type Server struct {
+	srv       *httptest.Server
+	mu        sync.Mutex
+	routes    []*Route
+	calls     []*Call
+	unmatched []*Call
+	fallback  http.Handler
+	onClose   func()
+}
+
+// NewServer 创建并启动模拟服务，使用完毕后需调用 Close
+func NewServer() *Server {
+	s := &Server{}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewTLSServer 创建并启动 HTTPS 模拟服务，可通过 Client 获取信任该服务证书的客户端
+func NewTLSServer() *Server {
+	s := &Server{}
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL 模拟服务地址，例：http://127.0.0.1:51234
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client 创建以模拟服务地址为基础地址的客户端
+func (s *Server) Client(opts ...x.HttpOption) *x.HttpClient {
+	opts = append([]x.HttpOption{x.HttpWithBaseURL(s.srv.URL), x.HttpWithTransport(s.srv.Client().Transport)}, opts...)
+	return x.NewHttpClient(opts...)
+}
+
+// Close 关闭模拟服务，录制模式下同时写入 golden 文件
+func (s *Server) Close() {
+	s.srv.Close()
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
+
+// On 注册路由，path 支持 path.Match 通配符，例：/api/*/detail。method 为空时匹配全部方法
+func (s *Server) On(method, path string) *Route {
+	r := &Route{s: s, method: strings.ToUpper(method), path: path, status: http.StatusOK, header: make(http.Header), times: -1}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = append(s.routes, r)
+	return r
+}
+
+// Calls 获取全部请求记录
+func (s *Server) Calls() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Call(nil), s.calls...)
+}
+
+// Unmatched 获取未匹配任何路由的请求记录
+func (s *Server) Unmatched() []*Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Call(nil), s.unmatched...)
+}
+
+// Reset 清除全部路由及请求记录
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes, s.calls, s.unmatched = nil, nil, nil
+}
+
+// AssertCalled 断言指定方法及路径至少被请求一次
+func (s *Server) AssertCalled(t TestingT, method, path string) bool {
+	t.Helper()
+	if s.countCalls(method, path) == 0 {
+		t.Errorf("httpmock: expected %s %s to be called, but it was not", method, path)
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled 断言指定方法及路径未被请求
+func (s *Server) AssertNotCalled(t TestingT, method, path string) bool {
+	t.Helper()
+	if n := s.countCalls(method, path); n > 0 {
+		t.Errorf("httpmock: expected %s %s not to be called, but it was called %d times", method, path, n)
+		return false
+	}
+	return true
+}
+
+// AssertExpectations 断言全部路由均已按 Times 设置的次数被请求（未设置时至少一次），且不存在未匹配的请求
+func (s *Server) AssertExpectations(t TestingT) bool {
+	t.Helper()
+	s.mu.Lock()
+	routes := append([]*Route(nil), s.routes...)
+	unmatched := append([]*Call(nil), s.unmatched...)
+	s.mu.Unlock()
+	ok := true
+	for _, r := range routes {
+		if !r.assert(t) {
+			ok = false
+		}
+	}
+	for _, c := range unmatched {
+		t.Errorf("httpmock: unexpected request %s %s", c.Method, c.Path)
+		ok = false
+	}
+	return ok
+}
+
+// countCalls 统计指定方法及路径的请求次数
+func (s *Server) countCalls(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, c := range s.calls {
+		if strings.EqualFold(c.Method, method) && c.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+// serveHTTP 匹配路由并返回预设响应
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	call := &Call{Method: req.Method, Path: req.URL.Path, Query: req.URL.Query(), Header: req.Header.Clone(), Body: body, Time: time.Now()}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, call)
+	var matched *Route
+	for _, r := range s.routes {
+		if r.match(call) {
+			matched = r
+			r.calls = append(r.calls, call)
+			break
+		}
+	}
+	var reply routeReply
+	if matched != nil {
+		reply = matched.reply()
+	}
+	fallback := s.fallback
+	if matched == nil && fallback == nil {
+		s.unmatched = append(s.unmatched, call)
+	}
+	s.mu.Unlock()
+
+	if matched == nil {
+		if fallback != nil {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		http.Error(w, fmt.Sprintf("httpmock: no route matched %s %s", req.Method, req.URL.Path), http.StatusNotFound)
+		return
+	}
+	reply.serve(w, req)
+}
+
+// Route 模拟服务路由，通过链式调用设置匹配条件及响应内容，设置方法持有服务锁，服务处理请求期间也可安全调用
+type Route struct {
+	s        *Server
+	method   string
+	path     string
+	query    url.Values
+	exact    url.Values
+	header   http.Header
+	matchers []func(body []byte) bool
+
+	status   int
+	respHead http.Header
+	respBody []byte
+	handler  http.HandlerFunc
+	delay    time.Duration
+	err      error // 响应内容编码失败的错误，请求时返回 500，并由 AssertExpectations 报告
+	times    int
+	calls    []*Call
+}
+
+// routeReply 路由响应设置的快照，在服务锁外输出响应
+type routeReply struct {
+	status  int
+	header  http.Header
+	body    []byte
+	handler http.HandlerFunc
+	delay   time.Duration
+	err     error
+}
+
+// set 持有服务锁修改路由设置
+func (r *Route) set(fn func()) *Route {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	fn()
+	return r
+}
+
+// WithQuery 匹配查询参数
+func (r *Route) WithQuery(key, value string) *Route {
+	return r.set(func() {
+		if r.query == nil {
+			r.query = make(url.Values)
+		}
+		r.query.Add(key, value)
+	})
+}
+
+// WithHeader 匹配请求头
+func (r *Route) WithHeader(key, value string) *Route {
+	return r.set(func() {
+		r.header.Add(key, value)
+	})
+}
+
+// WithBody 匹配请求内容包含指定字符串
+func (r *Route) WithBody(contains string) *Route {
+	return r.WithBodyFunc(func(body []byte) bool {
+		return bytes.Contains(body, []byte(contains))
+	})
+}
+
+// WithJsonBody 匹配请求内容与指定值的 JSON 结构一致（忽略字段顺序及空白）
+func (r *Route) WithJsonBody(v any) *Route {
+	expected, err := jsonNormalize(v)
+	return r.WithBodyFunc(func(body []byte) bool {
+		if err != nil {
+			return false
+		}
+		var actual any
+		if json.Unmarshal(body, &actual) != nil {
+			return false
+		}
+		return reflect.DeepEqual(expected, actual)
+	})
+}
+
+// WithBodyFunc 使用自定义函数匹配请求内容
+func (r *Route) WithBodyFunc(fn func(body []byte) bool) *Route {
+	return r.set(func() {
+		r.matchers = append(r.matchers, fn)
+	})
+}
+
+// Reply 设置响应状态码及内容
+func (r *Route) Reply(status int, body string) *Route {
+	return r.set(func() {
+		r.status, r.respBody, r.err = status, []byte(body), nil
+	})
+}
+
+// ReplyJson 设置 JSON 响应，v 为 string 或 []byte 时原样返回，其余类型编码为 JSON，
+// 编码失败时请求返回 500，并由 AssertExpectations 报告错误
+func (r *Route) ReplyJson(status int, v any) *Route {
+	data, err := toBytes(v, json.Marshal)
+	return r.set(func() {
+		r.status, r.respBody, r.err = status, data, err
+		r.setReplyHeader("Content-Type", "application/json;charset=utf-8")
+	})
+}
+
+// ReplyXml 设置 XML 响应，v 为 string 或 []byte 时原样返回，map[string]string 以 xml 为根节点编码，其余类型编码为 XML，
+// 编码失败时请求返回 500，并由 AssertExpectations 报告错误
+func (r *Route) ReplyXml(status int, v any) *Route {
+	var data []byte
+	var err error
+	if m, ok := v.(map[string]string); ok {
+		data = []byte(x.XMLFromMap(m, "xml"))
+	} else {
+		data, err = toBytes(v, xml.Marshal)
+	}
+	return r.set(func() {
+		r.status, r.respBody, r.err = status, data, err
+		r.setReplyHeader("Content-Type", "application/xml;charset=utf-8")
+	})
+}
+
+// ReplyHeader 设置响应头
+func (r *Route) ReplyHeader(key, value string) *Route {
+	return r.set(func() {
+		r.setReplyHeader(key, value)
+	})
+}
+
+// setReplyHeader 设置响应头，调用方需持有服务锁
+func (r *Route) setReplyHeader(key, value string) {
+	if r.respHead == nil {
+		r.respHead = make(http.Header)
+	}
+	r.respHead.Set(key, value)
+}
+
+// ReplyFunc 使用自定义处理函数生成响应，设置后其他响应设置不再生效
+func (r *Route) ReplyFunc(fn http.HandlerFunc) *Route {
+	return r.set(func() {
+		r.handler = fn
+	})
+}
+
+// Delay 设置响应延迟，用于测试超时
+func (r *Route) Delay(d time.Duration) *Route {
+	return r.set(func() {
+		r.delay = d
+	})
+}
+
+// Times 设置路由可匹配的次数，超出后不再匹配，AssertExpectations 校验实际次数
+func (r *Route) Times(n int) *Route {
+	return r.set(func() {
+		r.times = n
+	})
+}
+
+// Once 设置路由仅匹配一次
+func (r *Route) Once() *Route {
+	return r.Times(1)
+}
+
+// Calls 获取匹配该路由的请求记录
+func (r *Route) Calls() []*Call {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return append([]*Call(nil), r.calls...)
+}
+
+// match 请求是否匹配该路由，调用方需持有服务锁
+func (r *Route) match(c *Call) bool {
+	if r.times >= 0 && len(r.calls) >= r.times {
+		return false
+	}
+	if r.method != "" && r.method != c.Method {
+		return false
+	}
+	if ok, _ := path.Match(r.path, c.Path); !ok && r.path != c.Path {
+		return false
+	}
+	if r.exact != nil && r.exact.Encode() != c.Query.Encode() {
+		return false
+	}
+	for key, vals := range r.query {
+		for _, val := range vals {
+			if !x.SliceContains[string](c.Query[key], val) {
+				return false
+			}
+		}
+	}
+	for key, vals := range r.header {
+		for _, val := range vals {
+			if !x.SliceContains[string](c.Header.Values(key), val) {
+				return false
+			}
+		}
+	}
+	for _, fn := range r.matchers {
+		if !fn(c.Body) {
+			return false
+		}
+	}
+	return true
+}
+
+// reply 获取响应设置的快照，调用方需持有服务锁
+func (r *Route) reply() routeReply {
+	return routeReply{status: r.status, header: r.respHead.Clone(), body: r.respBody, handler: r.handler, delay: r.delay, err: r.err}
+}
+
+// serve 输出响应
+func (rp routeReply) serve(w http.ResponseWriter, req *http.Request) {
+	if rp.delay > 0 {
+		select {
+		case <-time.After(rp.delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if rp.handler != nil {
+		rp.handler(w, req)
+		return
+	}
+	if rp.err != nil {
+		http.Error(w, fmt.Sprintf("httpmock: marshal reply failed: %v", rp.err), http.StatusInternalServerError)
+		return
+	}
+	for key, vals := range rp.header {
+		w.Header()[key] = vals
+	}
+	w.WriteHeader(rp.status)
+	_, _ = w.Write(rp.body)
+}
+
+// assert 校验路由请求次数及响应内容编码结果
+func (r *Route) assert(t TestingT) bool {
+	t.Helper()
+	r.s.mu.Lock()
+	n, times, err := len(r.calls), r.times, r.err
+	r.s.mu.Unlock()
+	if err != nil {
+		t.Errorf("httpmock: %s %s marshal reply failed: %v", r.method, r.path, err)
+		return false
+	}
+	if times >= 0 && n != times {
+		t.Errorf("httpmock: expected %s %s to be called %d times, but it was called %d times", r.method, r.path, times, n)
+		return false
+	}
+	if times < 0 && n == 0 {
+		t.Errorf("httpmock: expected %s %s to be called, but it was not", r.method, r.path)
+		return false
+	}
+	return true
+}
+
+// toBytes 将响应值转换为字节内容
+func toBytes(v any, marshal func(any) ([]byte, error)) ([]byte, error) {
+	switch val := v.(type) {
+	case string:
+		return []byte(val), nil
+	case []byte:
+		return val, nil
+	}
+	return marshal(v)
+}
+
+// jsonNormalize 将任意值转换为 JSON 通用结构，便于比较
+func jsonNormalize(v any) (any, error) {
+	var data []byte
+	switch val := v.(type) {
+	case string:
+		data = []byte(val)
+	case []byte:
+		data = val
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out any
+	err := json.Unmarshal(data, &out)
+	return out, err
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package httpmock
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeT 记录断言错误的 TestingT
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServerMatch(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.On("GET", "/api/*/detail").WithQuery("id", "1").Reply(http.StatusOK, "detail")
+	s.On("POST", "/api/order").WithHeader("X-Token", "t1").WithJsonBody(map[string]any{"a": 1, "b": "x"}).ReplyJson(http.StatusCreated, map[string]int{"id": 7})
+	s.On("POST", "/api/order").WithBody("plain").ReplyXml(http.StatusOK, map[string]string{"code": "OK"})
+
+	c := s.Client()
+	ctx := context.Background()
+	if body, code, err := c.GetContext(ctx, "/api/user/detail?id=1&x=2"); err != nil || code != http.StatusOK || body != "detail" {
+		t.Fatalf("GET detail = %q, %d, %v", body, code, err)
+	}
+	if body, code, err := c.PostJsonContext(ctx, "/api/order", `{"b":"x", "a":1}`, map[string]string{"X-Token": "t1"}); err != nil || code != http.StatusCreated || body != `{"id":7}` {
+		t.Fatalf("POST json = %q, %d, %v", body, code, err)
+	}
+	if body, code, err := c.PostXmlContext(ctx, "/api/order", "plain text", nil); err != nil || code != http.StatusOK || !strings.Contains(body, "<code>OK</code>") {
+		t.Fatalf("POST xml = %q, %d, %v", body, code, err)
+	}
+	if _, code, _ := c.GetContext(ctx, "/api/user/detail?id=2"); code != http.StatusNotFound {
+		t.Fatalf("unmatched query = %d", code)
+	}
+	if len(s.Calls()) != 4 || len(s.Unmatched()) != 1 {
+		t.Fatalf("calls = %d, unmatched = %d", len(s.Calls()), len(s.Unmatched()))
+	}
+}
+
+func TestServerTimesAndAssert(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	once := s.On("GET", "/token").Once().Reply(http.StatusOK, "first")
+	s.On("GET", "/token").Reply(http.StatusOK, "again")
+	twice := s.On("DELETE", "/item").Times(2)
+
+	c := s.Client()
+	ctx := context.Background()
+	for i, want := range []string{"first", "again", "again"} {
+		if body, _, err := c.GetContext(ctx, "/token"); err != nil || body != want {
+			t.Fatalf("call %d = %q, %v", i, body, err)
+		}
+	}
+	if len(once.Calls()) != 1 {
+		t.Fatalf("Once route calls = %d", len(once.Calls()))
+	}
+	_, _, _ = c.SendContext(ctx, http.MethodDelete, "/item", nil, nil)
+
+	ft := &fakeT{}
+	if s.AssertExpectations(ft) || len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "called 2 times, but it was called 1 times") {
+		t.Fatalf("AssertExpectations errors = %v", ft.errors)
+	}
+	_, _, _ = c.SendContext(ctx, http.MethodDelete, "/item", nil, nil)
+	if ft = (&fakeT{}); !s.AssertExpectations(ft) || len(twice.Calls()) != 2 {
+		t.Fatalf("AssertExpectations errors = %v", ft.errors)
+	}
+	if !s.AssertCalled(t, "get", "/token") || !s.AssertNotCalled(t, "POST", "/token") {
+		t.Fatal("AssertCalled/AssertNotCalled failed")
+	}
+	if ft = (&fakeT{}); s.AssertNotCalled(ft, "GET", "/token") || len(ft.errors) != 1 {
+		t.Fatalf("AssertNotCalled errors = %v", ft.errors)
+	}
+}
+
+// 响应内容编码失败时返回 500 并由 AssertExpectations 报告，不能 panic
+func TestServerReplyMarshalError(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.On("GET", "/bad").ReplyJson(http.StatusOK, map[string]any{"ch": make(chan int)})
+	if _, code, _ := s.Client().GetContext(context.Background(), "/bad"); code != http.StatusInternalServerError {
+		t.Fatalf("code = %d", code)
+	}
+	ft := &fakeT{}
+	if s.AssertExpectations(ft) || len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "marshal reply failed") {
+		t.Fatalf("AssertExpectations errors = %v", ft.errors)
+	}
+}
+
+// 服务处理请求期间修改路由设置，需配合 -race 运行
+func TestServerConcurrentRouteUpdate(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	r := s.On("GET", "/ping").Reply(http.StatusOK, "pong")
+	c := s.Client()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_, _, _ = c.GetContext(context.Background(), "/ping")
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		r.ReplyHeader("X-Seq", fmt.Sprint(i)).Reply(http.StatusOK, fmt.Sprint("pong", i))
+	}
+	wg.Wait()
+	if len(r.Calls()) != 80 {
+		t.Fatalf("calls = %d", len(r.Calls()))
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	upstream := NewServer()
+	defer upstream.Close()
+	upstream.On("POST", "/pay").WithJsonBody(`{"amount":1}`).ReplyJson(http.StatusOK, `{"status":"ok"}`)
+	upstream.On("GET", "/bin").Reply(http.StatusOK, "\xff\xfe")
+
+	golden := filepath.Join(t.TempDir(), "testdata", "pay.golden.json")
+	rec := NewRecorder(upstream.URL(), golden)
+	c := rec.Client()
+	ctx := context.Background()
+	if body, _, err := c.PostJsonContext(ctx, "/pay?v=1", `{"amount":1}`, nil); err != nil || body != `{"status":"ok"}` {
+		t.Fatalf("record POST = %q, %v", body, err)
+	}
+	if body, _, err := c.GetContext(ctx, "/bin"); err != nil || body != "\xff\xfe" {
+		t.Fatalf("record GET = %q, %v", body, err)
+	}
+	rec.Close()
+	if _, err := os.Stat(golden); err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayer(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replay.Close()
+	c = replay.Client()
+	if _, code, _ := c.PostJsonContext(ctx, "/pay?v=2", `{"amount":1}`, nil); code != http.StatusNotFound {
+		t.Fatalf("replay with different query = %d", code)
+	}
+	if body, code, err := c.PostJsonContext(ctx, "/pay?v=1", `{ "amount" : 1 }`, nil); err != nil || code != http.StatusOK || body != `{"status":"ok"}` {
+		t.Fatalf("replay POST = %q, %d, %v", body, code, err)
+	}
+	if body, _, err := c.GetContext(ctx, "/bin"); err != nil || body != "\xff\xfe" {
+		t.Fatalf("replay GET = %q, %v", body, err)
+	}
+	if _, code, _ := c.GetContext(ctx, "/bin"); code != http.StatusNotFound {
+		t.Fatalf("replay beyond recorded count = %d", code)
+	}
+}
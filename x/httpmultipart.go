@@ -0,0 +1,120 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HttpMultipartPart multipart/form-data 表单分段
+type HttpMultipartPart struct {
+	Name     string               // 字段名称
+	FileName string               // 文件名称，为空时作为普通字段提交
+	Header   textproto.MIMEHeader // 附加分段头，可覆盖默认的 Content-Disposition、Content-Type
+	Reader   io.Reader            // 分段内容，实现 io.Closer 时提交后自动关闭
+	FilePath string               // 文件路径，Reader 为空时从该文件读取内容
+}
+
+// HttpMultipartField 创建普通字段分段
+func HttpMultipartField(name, value string) HttpMultipartPart {
+	return HttpMultipartPart{Name: name, Reader: strings.NewReader(value)}
+}
+
+// HttpMultipartFile 创建文件分段，文件名称取路径中的文件名
+func HttpMultipartFile(name, path string) HttpMultipartPart {
+	return HttpMultipartPart{Name: name, FileName: filepath.Base(path), FilePath: path}
+}
+
+// HttpMultipartReader 创建以 io.Reader 为内容的文件分段
+func HttpMultipartReader(name, fileName string, r io.Reader) HttpMultipartPart {
+	return HttpMultipartPart{Name: name, FileName: fileName, Reader: r}
+}
+
+// HttpPostMultipart 使用默认客户端执行 multipart/form-data 请求，请求内容以流的方式发送
+func HttpPostMultipart(ctx context.Context, requestUrl string, parts []HttpMultipartPart, headers map[string]string) *HttpResponse {
+	return HttpDefaultClient().postMultipart(ctx, "x.HttpPostMultipart", requestUrl, parts, headers)
+}
+
+// PostMultipart 执行 multipart/form-data 请求，请求内容以流的方式发送
+func (c *HttpClient) PostMultipart(ctx context.Context, requestUrl string, parts []HttpMultipartPart, headers map[string]string) *HttpResponse {
+	return c.postMultipart(ctx, "x.HttpClient.PostMultipart", requestUrl, parts, headers)
+}
+
+// postMultipart 执行 multipart/form-data 请求，op 用于错误信息前缀
+func (c *HttpClient) postMultipart(ctx context.Context, op, requestUrl string, parts []HttpMultipartPart, headers map[string]string) *HttpResponse {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		_ = pw.CloseWithError(httpWriteMultipart(mw, parts))
+	}()
+	r := c.execute(ctx, op, http.MethodPost, requestUrl, pr, mw.FormDataContentType(), headers)
+	_ = pr.Close()
+	return r
+}
+
+// httpWriteMultipart 依次写入全部分段
+func httpWriteMultipart(mw *multipart.Writer, parts []HttpMultipartPart) error {
+	for _, part := range parts {
+		if err := httpWritePart(mw, part); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// httpWritePart 写入单个分段
+func httpWritePart(mw *multipart.Writer, part HttpMultipartPart) error {
+	r := part.Reader
+	if r == nil && part.FilePath != "" {
+		f, err := os.Open(part.FilePath)
+		if err != nil {
+			return err
+		}
+		r = f
+	}
+	if closer, ok := r.(io.Closer); ok {
+		defer func(closer io.Closer) {
+			_ = closer.Close()
+		}(closer)
+	}
+	header := make(textproto.MIMEHeader)
+	if part.FileName != "" {
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data",
+			map[string]string{"name": part.Name, "filename": part.FileName}))
+		contentType := mime.TypeByExtension(filepath.Ext(part.FileName))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set("Content-Type", contentType)
+	} else {
+		header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": part.Name}))
+	}
+	for key, vals := range part.Header {
+		header[key] = vals
+	}
+	w, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return nil
+	}
+	if _, err = io.Copy(w, r); err != nil {
+		return fmt.Errorf("write part %q: %w", part.Name, err)
+	}
+	return nil
+}
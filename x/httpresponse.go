@@ -0,0 +1,155 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HttpStatusError 响应状态码非 2xx 时返回的错误，携带原始响应内容
+type HttpStatusError struct {
+	StatusCode int         // 响应状态码
+	Header     http.Header // 响应头
+	Body       []byte      // 原始响应内容
+}
+
+func (e *HttpStatusError) Error() string {
+	return fmt.Sprintf("x: http status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// HttpResponse HTTP 响应结果
+type HttpResponse struct {
+	StatusCode int           // 响应状态码，请求未完成时为 0
+	Header     http.Header   // 响应头
+	Body       []byte        // 原始响应内容
+	Elapsed    time.Duration // 请求耗时
+	Err        error         // 请求错误（网络、超时、取消等），不包含非 2xx 状态码
+}
+
+// HttpExecute 使用默认客户端执行请求，并以 HttpResponse 返回结果
+func HttpExecute(ctx context.Context, method, requestUrl string, body io.Reader, headers map[string]string) *HttpResponse {
+	return HttpDefaultClient().execute(ctx, "x.HttpExecute", method, requestUrl, body, "", headers)
+}
+
+// Execute 执行请求，并以 HttpResponse 返回结果
+func (c *HttpClient) Execute(ctx context.Context, method, requestUrl string, body io.Reader, headers map[string]string) *HttpResponse {
+	return c.execute(ctx, "x.HttpClient.Execute", method, requestUrl, body, "", headers)
+}
+
+// execute 执行请求并读取全部响应内容，op 用于错误信息前缀
+func (c *HttpClient) execute(ctx context.Context, op, method, requestUrl string, body io.Reader, contentType string, headers map[string]string) *HttpResponse {
+	start := time.Now()
+	r := &HttpResponse{}
+	resp, err := c.doContext(ctx, op, method, requestUrl, body, contentType, headers)
+	if err != nil {
+		r.Err = err
+		r.Elapsed = time.Since(start)
+		return r
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+	r.StatusCode = resp.StatusCode
+	r.Header = resp.Header
+	r.Body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		r.Err = &HttpError{Op: op, Msg: "Network Response Error", Err: err}
+	}
+	r.Elapsed = time.Since(start)
+	return r
+}
+
+// IsSuccess 请求是否成功且状态码为 2xx
+func (r *HttpResponse) IsSuccess() bool {
+	return r.Err == nil && r.StatusCode >= 200 && r.StatusCode < 300
+}
+
+// Error 返回请求错误，状态码非 2xx 时返回 *HttpStatusError
+func (r *HttpResponse) Error() error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		return &HttpStatusError{StatusCode: r.StatusCode, Header: r.Header, Body: r.Body}
+	}
+	return nil
+}
+
+// String 响应内容，string 类型
+func (r *HttpResponse) String() string {
+	return string(r.Body)
+}
+
+// ContentType 响应内容类型，不含参数，例：application/json
+func (r *HttpResponse) ContentType() string {
+	if r.Header == nil {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// Json 解析响应内容为 JsonNode
+func (r *HttpResponse) Json() (*JsonNode, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	return JsonFromStringE(string(r.Body))
+}
+
+// XmlMap 解析响应内容为 map[string]string，仅支持单层 XML 结构
+func (r *HttpResponse) XmlMap() (map[string]string, error) {
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	m := make(map[string]string)
+	if err := xml.Unmarshal(r.Body, (*xmlMap)(&m)); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DecodeJson 解析 JSON 响应内容到指定结构
+func (r *HttpResponse) DecodeJson(v any) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	return json.Unmarshal(r.Body, v)
+}
+
+// DecodeXml 解析 XML 响应内容到指定结构
+func (r *HttpResponse) DecodeXml(v any) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	return xml.Unmarshal(r.Body, v)
+}
+
+// Decode 根据响应内容类型解析到指定结构，XML 类型使用 XML 解析，其余使用 JSON 解析
+func (r *HttpResponse) Decode(v any) error {
+	ct := r.ContentType()
+	if strings.HasSuffix(ct, "/xml") || strings.HasSuffix(ct, "+xml") {
+		return r.DecodeXml(v)
+	}
+	if ct == "" && bytes.HasPrefix(bytes.TrimSpace(r.Body), []byte("<")) {
+		return r.DecodeXml(v)
+	}
+	return r.DecodeJson(v)
+}
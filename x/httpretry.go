@@ -0,0 +1,241 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrHttpCircuitOpen 目标主机熔断中，请求未发出
+var ErrHttpCircuitOpen = errors.New("x: http circuit breaker is open")
+
+const (
+	httpRetryBaseDelay = 100 * time.Millisecond // 默认初始退避时间
+	httpRetryMaxDelay  = 10 * time.Second       // 默认最大退避时间
+)
+
+// HttpRetryPolicy 请求重试策略，退避时间为 BaseDelay*2^n 并加入随机抖动
+type HttpRetryPolicy struct {
+	MaxAttempts        int           // 最大尝试次数（含首次请求），小于等于 1 时不重试
+	BaseDelay          time.Duration // 初始退避时间，默认 100ms
+	MaxDelay           time.Duration // 最大退避时间，默认 10s，Retry-After 超过该值时不再重试
+	RetryStatus        []int         // 需重试的响应状态码，为空时使用 429、502、503、504
+	RetryNonIdempotent bool          // 是否重试非幂等请求（POST、PATCH 等），默认仅重试幂等请求及携带 Idempotency-Key 的请求
+}
+
+// HttpWithRetry 设置请求重试策略
+func HttpWithRetry(policy HttpRetryPolicy) HttpOption {
+	return func(o *httpClientOptions) {
+		o.retry = &policy
+	}
+}
+
+// HttpWithCircuitBreaker 设置熔断器，同一熔断器可在多个客户端间共享
+func HttpWithCircuitBreaker(breaker *HttpCircuitBreaker) HttpOption {
+	return func(o *httpClientOptions) {
+		o.breaker = breaker
+	}
+}
+
+// retryable 请求是否允许重试
+func (p *HttpRetryPolicy) retryable(req *http.Request) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if p.RetryNonIdempotent || req.Header.Get("Idempotency-Key") != "" {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retryStatus 响应状态码是否需要重试
+func (p *HttpRetryPolicy) retryStatus(code int) bool {
+	if len(p.RetryStatus) == 0 {
+		return code == http.StatusTooManyRequests || code == http.StatusBadGateway ||
+			code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+	}
+	return SliceContains[int](p.RetryStatus, code)
+}
+
+// backoff 计算第 attempt 次重试前的等待时间，返回 false 表示不再重试
+func (p *HttpRetryPolicy) backoff(attempt int, resp *http.Response) (time.Duration, bool) {
+	base, maximum := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = httpRetryBaseDelay
+	}
+	if maximum <= 0 {
+		maximum = httpRetryMaxDelay
+	}
+	if resp != nil {
+		if wait, ok := httpRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, wait <= maximum
+		}
+	}
+	delay := maximum
+	if attempt < 32 && base<<attempt > 0 && base<<attempt < maximum {
+		delay = base << attempt
+	}
+	return time.Duration(rand.Int63n(int64(delay)) + 1), true
+}
+
+// httpRetryAfter 解析 Retry-After 响应头，支持秒数及 HTTP 日期格式
+func httpRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(t); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// doRetry 按重试策略及熔断器发送请求
+func (c *HttpClient) doRetry(req *http.Request) (*http.Response, error) {
+	retryable := c.retry.retryable(req)
+	for attempt := 0; ; attempt++ {
+		if c.breaker != nil {
+			if err := c.breaker.allow(req.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := c.intercept(req)
+		if c.breaker != nil {
+			c.breaker.record(req.URL.Host, err == nil && resp.StatusCode < 500)
+		}
+		if !retryable || attempt+1 >= c.retry.MaxAttempts || req.Context().Err() != nil {
+			return resp, err
+		}
+		if err == nil && !c.retry.retryStatus(resp.StatusCode) {
+			return resp, nil
+		}
+		wait, ok := c.retry.backoff(attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if req.GetBody != nil {
+			body, e := req.GetBody()
+			if e != nil {
+				return resp, err
+			}
+			req.Body = body
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// HttpCircuitBreaker 按主机统计的熔断器，连续失败达到阈值后在冷却时间内直接返回 ErrHttpCircuitOpen，
+// 冷却结束后放行一次试探请求，成功则恢复，失败则重新熔断
+type HttpCircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mu        sync.Mutex
+	hosts     map[string]*httpBreakerState
+}
+
+// httpBreakerState 单个主机的熔断状态
+type httpBreakerState struct {
+	failures  int       // 连续失败次数
+	openUntil time.Time // 熔断截止时间
+	probing   bool      // 是否有试探请求进行中
+}
+
+// NewHttpCircuitBreaker 创建熔断器，threshold：连续失败次数阈值（网络错误或 5xx），cooldown：熔断时长
+func NewHttpCircuitBreaker(threshold int, cooldown time.Duration) *HttpCircuitBreaker {
+	if threshold <= 0 {
+		threshold = 5
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &HttpCircuitBreaker{threshold: threshold, cooldown: cooldown, hosts: make(map[string]*httpBreakerState)}
+}
+
+// IsOpen 指定主机是否处于熔断状态
+func (b *HttpCircuitBreaker) IsOpen(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.hosts[host]
+	return ok && s.failures >= b.threshold && (time.Now().Before(s.openUntil) || s.probing)
+}
+
+// Reset 重置指定主机的熔断状态
+func (b *HttpCircuitBreaker) Reset(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.hosts, host)
+}
+
+// allow 检查是否允许向指定主机发送请求
+func (b *HttpCircuitBreaker) allow(host string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.hosts[host]
+	if !ok || s.failures < b.threshold {
+		return nil
+	}
+	if time.Now().Before(s.openUntil) || s.probing {
+		return fmt.Errorf("%w: %s", ErrHttpCircuitOpen, host)
+	}
+	s.probing = true
+	return nil
+}
+
+// record 记录请求结果
+func (b *HttpCircuitBreaker) record(host string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.hosts[host]
+	if success {
+		if ok {
+			delete(b.hosts, host)
+		}
+		return
+	}
+	if !ok {
+		s = &httpBreakerState{}
+		b.hosts[host] = s
+	}
+	s.failures++
+	s.probing = false
+	if s.failures >= b.threshold {
+		s.openUntil = time.Now().Add(b.cooldown)
+	}
+}
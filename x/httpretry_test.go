@@ -0,0 +1,288 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// retryTestServer 前 fails 次请求返回 status，之后返回 200，记录每次收到的请求体
+type retryTestServer struct {
+	*httptest.Server
+	fails      int32
+	status     int
+	retryAfter string
+	count      atomic.Int32
+	mu         sync.Mutex
+	bodies     []string
+}
+
+func newRetryTestServer(t *testing.T, fails int32, status int, retryAfter string) *retryTestServer {
+	s := &retryTestServer{fails: fails, status: status, retryAfter: retryAfter}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+		if s.count.Add(1) <= s.fails {
+			if s.retryAfter != "" {
+				w.Header().Set("Retry-After", s.retryAfter)
+			}
+			w.WriteHeader(s.status)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// received 已收到的请求体
+func (s *retryTestServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func retryTestDo(t *testing.T, c *HttpClient, method, url string, body io.Reader, header map[string]string) *http.Response {
+	t.Helper()
+	req, err := c.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, val := range header {
+		req.Header.Set(key, val)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	return resp
+}
+
+func TestHttpRetryStatus(t *testing.T) {
+	s := newRetryTestServer(t, 2, http.StatusServiceUnavailable, "")
+	c := NewHttpClient(HttpWithRetry(HttpRetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	if resp := retryTestDo(t, c, http.MethodGet, s.URL, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if n := s.count.Load(); n != 3 {
+		t.Fatalf("attempts = %d, want 3", n)
+	}
+
+	s = newRetryTestServer(t, 5, http.StatusServiceUnavailable, "")
+	if resp := retryTestDo(t, c, http.MethodGet, s.URL, nil, nil); resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if n := s.count.Load(); n != 3 {
+		t.Fatalf("attempts = %d, want 3", n)
+	}
+
+	s = newRetryTestServer(t, 1, http.StatusInternalServerError, "")
+	retryTestDo(t, c, http.MethodGet, s.URL, nil, nil)
+	if n := s.count.Load(); n != 1 {
+		t.Fatalf("500 attempts = %d, want 1", n)
+	}
+}
+
+func TestHttpRetryAfter(t *testing.T) {
+	s := newRetryTestServer(t, 1, http.StatusTooManyRequests, "0")
+	c := NewHttpClient(HttpWithRetry(HttpRetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}))
+	start := time.Now()
+	if resp := retryTestDo(t, c, http.MethodGet, s.URL, nil, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Retry-After: 0 waited %v", elapsed)
+	}
+
+	// Retry-After 超过 MaxDelay 时直接返回响应，不再重试
+	s = newRetryTestServer(t, 1, http.StatusTooManyRequests, "120")
+	c = NewHttpClient(HttpWithRetry(HttpRetryPolicy{MaxAttempts: 3, MaxDelay: time.Second}))
+	if resp := retryTestDo(t, c, http.MethodGet, s.URL, nil, nil); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if n := s.count.Load(); n != 1 {
+		t.Fatalf("attempts = %d, want 1", n)
+	}
+}
+
+func TestHttpRetryAfterParse(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+		ok    bool
+	}{
+		{"", 0, 0, false},
+		{"abc", 0, 0, false},
+		{"0", 0, 0, true},
+		{"-5", 0, 0, true},
+		{"30", 30 * time.Second, 30 * time.Second, true},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 55 * time.Second, time.Minute, true},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0, true},
+	}
+	for _, tt := range tests {
+		wait, ok := httpRetryAfter(tt.value)
+		if ok != tt.ok || wait < tt.min || wait > tt.max {
+			t.Errorf("httpRetryAfter(%q) = %v, %v", tt.value, wait, ok)
+		}
+	}
+}
+
+func TestHttpRetryNonIdempotent(t *testing.T) {
+	policy := HttpRetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	c := NewHttpClient(HttpWithRetry(policy))
+	s := newRetryTestServer(t, 5, http.StatusServiceUnavailable, "")
+	retryTestDo(t, c, http.MethodPost, s.URL, strings.NewReader("data"), nil)
+	if n := s.count.Load(); n != 1 {
+		t.Fatalf("POST attempts = %d, want 1", n)
+	}
+
+	s = newRetryTestServer(t, 5, http.StatusServiceUnavailable, "")
+	retryTestDo(t, c, http.MethodPost, s.URL, strings.NewReader("data"), map[string]string{"Idempotency-Key": "k1"})
+	if n := s.count.Load(); n != 3 {
+		t.Fatalf("POST with Idempotency-Key attempts = %d, want 3", n)
+	}
+
+	policy.RetryNonIdempotent = true
+	c = NewHttpClient(HttpWithRetry(policy))
+	s = newRetryTestServer(t, 5, http.StatusServiceUnavailable, "")
+	retryTestDo(t, c, http.MethodPatch, s.URL, strings.NewReader("data"), nil)
+	if n := s.count.Load(); n != 3 {
+		t.Fatalf("PATCH with RetryNonIdempotent attempts = %d, want 3", n)
+	}
+}
+
+func TestHttpRetryGetBody(t *testing.T) {
+	c := NewHttpClient(HttpWithRetry(HttpRetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	s := newRetryTestServer(t, 2, http.StatusBadGateway, "")
+	if resp := retryTestDo(t, c, http.MethodPut, s.URL, strings.NewReader("payload"), nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	bodies := s.received()
+	if len(bodies) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(bodies))
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Fatalf("attempt %d body = %q", i+1, body)
+		}
+	}
+
+	// 请求体无法重放时不重试
+	s = newRetryTestServer(t, 2, http.StatusBadGateway, "")
+	retryTestDo(t, c, http.MethodPut, s.URL, io.NopCloser(strings.NewReader("payload")), nil)
+	if n := s.count.Load(); n != 1 {
+		t.Fatalf("attempts without GetBody = %d, want 1", n)
+	}
+}
+
+func TestHttpCircuitBreaker(t *testing.T) {
+	var failing atomic.Bool
+	var hits atomic.Int32
+	release := make(chan struct{})
+	var blocking atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if blocking.Load() {
+			<-release
+		}
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	cooldown := 50 * time.Millisecond
+	breaker := NewHttpCircuitBreaker(2, cooldown)
+	c := NewHttpClient(HttpWithCircuitBreaker(breaker))
+	do := func() (*http.Response, error) {
+		req, _ := c.NewRequest(http.MethodGet, srv.URL, nil)
+		resp, err := c.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	// closed -> open
+	for i := 0; i < 2; i++ {
+		if resp, err := do(); err != nil || resp.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if !breaker.IsOpen(host) {
+		t.Fatal("breaker should be open")
+	}
+	if _, err := do(); !errors.Is(err, ErrHttpCircuitOpen) {
+		t.Fatalf("open: err = %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("hits = %d, want 2", n)
+	}
+
+	// open -> half-open -> open：试探失败重新熔断
+	time.Sleep(cooldown + 10*time.Millisecond)
+	if resp, err := do(); err != nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("failed probe: %v", err)
+	}
+	if _, err := do(); !errors.Is(err, ErrHttpCircuitOpen) {
+		t.Fatalf("reopened: err = %v", err)
+	}
+
+	// open -> half-open：仅放行一个试探请求
+	time.Sleep(cooldown + 10*time.Millisecond)
+	failing.Store(false)
+	blocking.Store(true)
+	done := make(chan error, 1)
+	go func() {
+		resp, err := do()
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = errors.New(resp.Status)
+		}
+		done <- err
+	}()
+	for hits.Load() != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	if !breaker.IsOpen(host) {
+		t.Fatal("breaker should stay open while probing")
+	}
+	if _, err := do(); !errors.Is(err, ErrHttpCircuitOpen) {
+		t.Fatalf("half-open: err = %v", err)
+	}
+	blocking.Store(false)
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+
+	// half-open -> closed
+	if breaker.IsOpen(host) {
+		t.Fatal("breaker should be closed")
+	}
+	if resp, err := do(); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("closed: %v", err)
+	}
+	if n := hits.Load(); n != 5 {
+		t.Fatalf("hits = %d, want 5", n)
+	}
+}
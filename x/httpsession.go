@@ -0,0 +1,281 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gbox "github.com/mvity/go-box"
+)
+
+// HttpStoredCookie 持久化存储的 Cookie
+type HttpStoredCookie struct {
+	Url      string        `json:"url"`                // 设置该 Cookie 的请求地址
+	Name     string        `json:"name"`               // 名称
+	Value    string        `json:"value"`              // 值
+	Path     string        `json:"path,omitempty"`     // 路径
+	Domain   string        `json:"domain,omitempty"`   // 域名
+	Expires  time.Time     `json:"expires,omitempty"`  // 过期时间，零值表示会话 Cookie
+	Secure   bool          `json:"secure,omitempty"`   // 是否仅 HTTPS
+	HttpOnly bool          `json:"httpOnly,omitempty"` // 是否禁止脚本访问
+	SameSite http.SameSite `json:"sameSite,omitempty"` // SameSite 策略
+}
+
+// HttpCookieStore Cookie 持久化存储接口
+type HttpCookieStore interface {
+	Load() ([]HttpStoredCookie, error)     // 加载全部 Cookie
+	Save(cookies []HttpStoredCookie) error // 保存全部 Cookie
+}
+
+// HttpFileCookieStore 创建以 JSON 文件存储 Cookie 的持久化存储，文件不存在时视为空
+func HttpFileCookieStore(file string) HttpCookieStore {
+	return &httpFileCookieStore{file: file}
+}
+
+// httpFileCookieStore JSON 文件 Cookie 存储
+type httpFileCookieStore struct {
+	file string
+	mu   sync.Mutex
+}
+
+func (s *httpFileCookieStore) Load() ([]HttpStoredCookie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cookies []HttpStoredCookie
+	if err = json.Unmarshal(data, &cookies); err != nil {
+		return nil, err
+	}
+	return cookies, nil
+}
+
+func (s *httpFileCookieStore) Save(cookies []HttpStoredCookie) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.file), 0700); err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// HttpSession 保持 Cookie 的 HTTP 会话，可选持久化存储，收到新的或有变化的 Cookie 时同步保存
+type HttpSession struct {
+	*HttpClient
+	jar *httpSessionJar
+}
+
+// NewHttpSession 创建 HTTP 会话，store 为 nil 时 Cookie 仅保存在内存中，opts 同 NewHttpClient
+func NewHttpSession(store HttpCookieStore, opts ...HttpOption) (*HttpSession, error) {
+	inner, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	jar := &httpSessionJar{jar: inner, store: store, entries: make(map[string]HttpStoredCookie)}
+	if store != nil {
+		cookies, err := store.Load()
+		if err != nil {
+			return nil, err
+		}
+		jar.restore(cookies)
+	}
+	opts = append(opts, HttpWithCookieJar(jar))
+	c, err := NewHttpClientE(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &HttpSession{HttpClient: c, jar: jar}, nil
+}
+
+// Cookies 获取指定地址可用的 Cookie
+func (s *HttpSession) Cookies(requestUrl string) []*http.Cookie {
+	u, err := url.Parse(s.resolve(requestUrl))
+	if err != nil {
+		return nil
+	}
+	return s.jar.Cookies(u)
+}
+
+// SetCookies 为指定地址设置 Cookie，例：登录令牌
+func (s *HttpSession) SetCookies(requestUrl string, cookies []*http.Cookie) error {
+	u, err := url.Parse(s.resolve(requestUrl))
+	if err != nil {
+		return err
+	}
+	s.jar.SetCookies(u, cookies)
+	return nil
+}
+
+// Save 保存 Cookie 到持久化存储
+func (s *HttpSession) Save() error {
+	return s.jar.save()
+}
+
+// Clear 清除全部 Cookie 并同步到持久化存储
+func (s *HttpSession) Clear() error {
+	inner, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	s.jar.mu.Lock()
+	s.jar.jar = inner
+	s.jar.entries = make(map[string]HttpStoredCookie)
+	s.jar.mu.Unlock()
+	return s.jar.save()
+}
+
+// httpSessionJar 记录全部 Cookie 以便持久化的 CookieJar
+type httpSessionJar struct {
+	saveMu  sync.Mutex
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	store   HttpCookieStore
+	entries map[string]HttpStoredCookie
+}
+
+func (j *httpSessionJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// SetCookies 实现 http.CookieJar 接口，仅记录内部 cookiejar 接受的 Cookie（例：域名与请求地址不匹配的 Cookie 将被拒绝）。
+// 设置了持久化存储且 Cookie 有变化时同步写入存储，每个带新 Cookie 的响应都会触发一次写入，
+// 请求频繁且存储较慢时可使用自定义 HttpCookieStore 合并写入
+func (j *httpSessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	j.jar.SetCookies(u, cookies)
+	now := time.Now()
+	changed := false
+	for _, c := range cookies {
+		sc := HttpStoredCookie{Url: u.Scheme + "://" + u.Host + u.Path, Name: c.Name, Value: c.Value, Path: c.Path,
+			Domain: c.Domain, Expires: c.Expires, Secure: c.Secure, HttpOnly: c.HttpOnly, SameSite: c.SameSite}
+		if c.MaxAge > 0 {
+			sc.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		key := httpCookieKey(u, c.Domain, c.Path, c.Name)
+		old, exists := j.entries[key]
+		switch {
+		case c.MaxAge < 0 || (!sc.Expires.IsZero() && !sc.Expires.After(now)):
+			if exists {
+				delete(j.entries, key)
+				changed = true
+			}
+		case !j.accepted(u, key, c):
+		case !exists || old != sc:
+			j.entries[key] = sc
+			changed = true
+		}
+	}
+	j.mu.Unlock()
+	if changed && j.store != nil {
+		if err := j.save(); err != nil {
+			gbox.WARN("[x.HttpSession] save cookies error: %v", err)
+		}
+	}
+}
+
+// accepted 内部 cookiejar 是否已接受该 Cookie：按 Cookie 的有效域名及路径查询，存在同名同值的 Cookie 即视为接受
+func (j *httpSessionJar) accepted(u *url.URL, key string, c *http.Cookie) bool {
+	parts := strings.SplitN(key, ";", 3)
+	scheme := u.Scheme
+	if c.Secure {
+		scheme = "https"
+	}
+	host := parts[0]
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	for _, got := range j.jar.Cookies(&url.URL{Scheme: scheme, Host: host, Path: parts[1]}) {
+		if got.Name == c.Name && got.Value == c.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// restore 从持久化数据恢复 Cookie，已过期的 Cookie 将被忽略
+func (j *httpSessionJar) restore(cookies []HttpStoredCookie) {
+	now := time.Now()
+	for _, sc := range cookies {
+		if !sc.Expires.IsZero() && !sc.Expires.After(now) {
+			continue
+		}
+		u, err := url.Parse(sc.Url)
+		if err != nil {
+			continue
+		}
+		c := &http.Cookie{Name: sc.Name, Value: sc.Value, Path: sc.Path, Domain: sc.Domain, Expires: sc.Expires,
+			Secure: sc.Secure, HttpOnly: sc.HttpOnly, SameSite: sc.SameSite}
+		j.jar.SetCookies(u, []*http.Cookie{c})
+		j.entries[httpCookieKey(u, sc.Domain, sc.Path, sc.Name)] = sc
+	}
+}
+
+// httpCookieKey 生成与 cookiejar 一致的 Cookie 标识：有效域名;有效路径;名称，
+// 未指定 Domain 时取请求主机名（不含端口），未指定 Path 时取请求路径所在目录
+func httpCookieKey(u *url.URL, domain, path, name string) string {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+	if domain == "" {
+		domain = strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	}
+	if path == "" || path[0] != '/' {
+		path = "/"
+		if i := strings.LastIndex(u.Path, "/"); i > 0 && u.Path[0] == '/' {
+			path = u.Path[:i]
+		}
+	}
+	return domain + ";" + path + ";" + name
+}
+
+// save 保存全部未过期 Cookie
+func (j *httpSessionJar) save() error {
+	if j.store == nil {
+		return nil
+	}
+	j.saveMu.Lock()
+	defer j.saveMu.Unlock()
+	j.mu.Lock()
+	now := time.Now()
+	keys := make([]string, 0, len(j.entries))
+	for key, sc := range j.entries {
+		if sc.Expires.IsZero() || sc.Expires.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	cookies := make([]HttpStoredCookie, len(keys))
+	for i, key := range keys {
+		cookies[i] = j.entries[key]
+	}
+	j.mu.Unlock()
+	return j.store.Save(cookies)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// sessionTestStore 记录保存次数的内存 Cookie 存储
+type sessionTestStore struct {
+	cookies []HttpStoredCookie
+	saves   int
+}
+
+func (s *sessionTestStore) Load() ([]HttpStoredCookie, error) {
+	return s.cookies, nil
+}
+
+func (s *sessionTestStore) Save(cookies []HttpStoredCookie) error {
+	s.cookies, s.saves = cookies, s.saves+1
+	return nil
+}
+
+func TestHttpSessionPersistsAcceptedCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc"})
+		http.SetCookie(w, &http.Cookie{Name: "admin", Value: "1", Path: "/admin"})
+		http.SetCookie(w, &http.Cookie{Name: "evil", Value: "x", Domain: "evil.example.com"})
+	}))
+	defer srv.Close()
+	store := &sessionTestStore{}
+	s, err := NewHttpSession(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err = s.GetContext(context.Background(), srv.URL+"/login"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := make(map[string]bool)
+	for _, c := range store.cookies {
+		names[c.Name] = true
+	}
+	if len(store.cookies) != 2 || !names["sid"] || !names["admin"] {
+		t.Fatalf("stored cookies = %+v", store.cookies)
+	}
+	if store.saves != 1 {
+		t.Fatalf("saves = %d, want 1 for unchanged cookies", store.saves)
+	}
+	if got := s.Cookies(srv.URL + "/admin/x"); len(got) != 2 {
+		t.Fatalf("Cookies(/admin/x) = %v", got)
+	}
+}
+
+func TestHttpSessionFileStoreRestore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/logout" {
+			http.SetCookie(w, &http.Cookie{Name: "sid", MaxAge: -1})
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc", MaxAge: 3600})
+	}))
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "cookies.json")
+	s, err := NewHttpSession(HttpFileCookieStore(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = s.GetContext(context.Background(), srv.URL+"/login"); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewHttpSession(HttpFileCookieStore(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Cookies(srv.URL); len(got) != 1 || got[0].Value != "abc" {
+		t.Fatalf("restored cookies = %v", got)
+	}
+	if _, _, err = restored.GetContext(context.Background(), srv.URL+"/logout"); err != nil {
+		t.Fatal(err)
+	}
+	if cookies, err := HttpFileCookieStore(file).Load(); err != nil || len(cookies) != 0 {
+		t.Fatalf("after logout = %v, %v", cookies, err)
+	}
+}
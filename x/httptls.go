@@ -0,0 +1,174 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"os"
+)
+
+// ErrHttpTLSPinMismatch 服务端证书公钥与固定公钥均不匹配
+var ErrHttpTLSPinMismatch = errors.New("x: tls certificate pin mismatch")
+
+// HttpTLSOption TLS 配置选项
+type HttpTLSOption func(conf *tls.Config) error
+
+// NewHttpTLSConfig 创建 TLS 配置，默认最低版本 TLS 1.2，配合 HttpWithTLSConfig 用于 HttpClient
+func NewHttpTLSConfig(opts ...HttpTLSOption) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	for _, opt := range opts {
+		if err := opt(conf); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// HttpTLSClientCertFile 从 PEM 文件加载客户端证书及私钥
+func HttpTLSClientCertFile(certFile, keyFile string) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		conf.Certificates = append(conf.Certificates, cert)
+		return nil
+	}
+}
+
+// HttpTLSClientCertPEM 从 PEM 内容加载客户端证书及私钥
+func HttpTLSClientCertPEM(certPEM, keyPEM []byte) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return err
+		}
+		conf.Certificates = append(conf.Certificates, cert)
+		return nil
+	}
+}
+
+// HttpTLSPKCS12File 从 PKCS#12（.p12/.pfx）文件加载客户端证书及私钥
+func HttpTLSPKCS12File(file, password string) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return HttpTLSPKCS12(data, password)(conf)
+	}
+}
+
+// HttpTLSPKCS12 从 PKCS#12 内容加载客户端证书及私钥，以与私钥匹配的证书作为终端证书
+func HttpTLSPKCS12(data []byte, password string) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		key, cert, chain, err := pkcs12DecodeLeaf(data, password)
+		if err != nil {
+			return err
+		}
+		tc := tls.Certificate{PrivateKey: key, Leaf: cert, Certificate: [][]byte{cert.Raw}}
+		for _, c := range chain {
+			tc.Certificate = append(tc.Certificate, c.Raw)
+		}
+		conf.Certificates = append(conf.Certificates, tc)
+		return nil
+	}
+}
+
+// HttpTLSRootCAFile 从 PEM 文件追加信任的根证书，默认在系统根证书基础上追加
+func HttpTLSRootCAFile(files ...string) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err = HttpTLSRootCAPEM(data)(conf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// HttpTLSRootCAPEM 从 PEM 内容追加信任的根证书，默认在系统根证书基础上追加，
+// 已通过 HttpTLSRootCAs 指定证书池时在其副本上追加，不修改调用方的证书池
+func HttpTLSRootCAPEM(pem []byte) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		var pool *x509.CertPool
+		if conf.RootCAs != nil {
+			pool = conf.RootCAs.Clone()
+		} else if sys, err := x509.SystemCertPool(); err == nil {
+			pool = sys
+		} else {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("x: no valid certificate found in root CA PEM")
+		}
+		conf.RootCAs = pool
+		return nil
+	}
+}
+
+// HttpTLSRootCAs 指定信任的根证书池，替换系统根证书
+func HttpTLSRootCAs(pool *x509.CertPool) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		conf.RootCAs = pool
+		return nil
+	}
+}
+
+// HttpTLSMinVersion 设置最低 TLS 版本，例：tls.VersionTLS13
+func HttpTLSMinVersion(version uint16) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		conf.MinVersion = version
+		return nil
+	}
+}
+
+// HttpTLSServerName 设置校验证书时使用的服务端名称
+func HttpTLSServerName(name string) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		conf.ServerName = name
+		return nil
+	}
+}
+
+// HttpTLSPinSHA256 固定服务端证书公钥，pins 为证书链中任一证书 SubjectPublicKeyInfo 的 SHA256 摘要（Base64 编码），
+// 可通过 HttpTLSPublicKeyPin 计算。固定校验在常规证书校验之后执行，仅匹配已校验的证书链，
+// 服务端额外发送的证书不参与匹配，跳过证书校验（InsecureSkipVerify）时校验始终失败
+func HttpTLSPinSHA256(pins ...string) HttpTLSOption {
+	return func(conf *tls.Config) error {
+		set := make(map[string]struct{}, len(pins))
+		for _, pin := range pins {
+			set[pin] = struct{}{}
+		}
+		conf.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, chain := range cs.VerifiedChains {
+				for _, cert := range chain {
+					if _, ok := set[HttpTLSPublicKeyPin(cert)]; ok {
+						return nil
+					}
+				}
+			}
+			return ErrHttpTLSPinMismatch
+		}
+		return nil
+	}
+}
+
+// HttpTLSPublicKeyPin 计算证书公钥固定值：SubjectPublicKeyInfo 的 SHA256 摘要，Base64 编码
+func HttpTLSPublicKeyPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// tlsTestCert 生成测试证书，parent 为 nil 时生成自签名 CA 证书
+func tlsTestCert(t *testing.T, cn string, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		tpl.IsCA, tpl.BasicConstraintsValid = true, true
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestHttpTLSRootCAPEMKeepsCallerPool(t *testing.T) {
+	ca1, _ := tlsTestCert(t, "ca1", nil, nil)
+	ca2, _ := tlsTestCert(t, "ca2", nil, nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca1)
+	conf, err := NewHttpTLSConfig(HttpTLSRootCAs(pool), HttpTLSRootCAPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca2.Raw})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify := func(pool *x509.CertPool, cert *x509.Certificate) bool {
+		_, err := cert.Verify(x509.VerifyOptions{Roots: pool})
+		return err == nil
+	}
+	if !verify(conf.RootCAs, ca1) || !verify(conf.RootCAs, ca2) {
+		t.Fatal("config pool missing certificates")
+	}
+	if verify(pool, ca2) {
+		t.Fatal("caller pool was modified")
+	}
+}
+
+// 证书链中终端证书不在首位时，应以与私钥匹配的证书作为终端证书
+func TestHttpTLSPKCS12Leaf(t *testing.T) {
+	ca, caKey := tlsTestCert(t, "ca", nil, nil)
+	leaf, leafKey := tlsTestCert(t, "leaf", ca, caKey)
+	for name, order := range map[string][]*x509.Certificate{"leaf first": {leaf, ca}, "ca first": {ca, leaf}} {
+		data, err := pkcs12.Modern.Encode(leafKey, order[0], order[1:], "pw")
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf, err := NewHttpTLSConfig(HttpTLSPKCS12(data, "pw"))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		tc := conf.Certificates[0]
+		if tc.Leaf.Subject.CommonName != "leaf" || len(tc.Certificate) != 2 || string(tc.Certificate[0]) != string(leaf.Raw) {
+			t.Fatalf("%s: leaf = %s, chain = %d", name, tc.Leaf.Subject.CommonName, len(tc.Certificate))
+		}
+	}
+	if _, err := NewHttpTLSConfig(HttpTLSPKCS12([]byte("not a p12"), "pw")); err == nil {
+		t.Fatal("invalid PKCS#12 accepted")
+	}
+}
@@ -1,5 +1,5 @@
 /*
- * Copyright © 2021 - 2023 vity <vityme@icloud.com>.
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
  *
  * Use of this source code is governed by an MIT-style
  * license that can be found in the LICENSE file.
@@ -8,10 +8,24 @@
 package x
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrJsonNull           = errors.New("null or missing value") // 值为 null 或不存在
+	ErrJsonInvalidNumber  = errors.New("invalid number value")  // 非法数值
+	ErrJsonInvalidBoolean = errors.New("invalid boolean value") // 非法布尔值
+	ErrJsonInvalidTime    = errors.New("invalid time value")    // 非法时间值
 )
 
 type jsonType int8
@@ -33,25 +47,29 @@ func JsonFromStringE(jsonStr string) (*JsonNode, error) {
 	node.rawString = &jsonStr
 	if strings.HasPrefix(jsonStr, "{") && strings.HasSuffix(jsonStr, "}") {
 		var jval map[string]any
-		if err := json.Unmarshal([]byte(jsonStr), &jval); err != nil {
+		if err := jsonUnmarshal([]byte(jsonStr), &jval); err != nil {
 			return nil, err
 		}
 		if rst, err := node.parseMap(jval); err != nil {
 			return nil, err
 		} else {
 			node.object = rst.object
+			node.jType = jsonObject
+			node.link()
 			return node, nil
 		}
 
 	} else if strings.HasPrefix(jsonStr, "[") && strings.HasSuffix(jsonStr, "]") {
 		var jval []any
-		if err := json.Unmarshal([]byte(jsonStr), &jval); err != nil {
+		if err := jsonUnmarshal([]byte(jsonStr), &jval); err != nil {
 			return nil, err
 		}
 		if rst, err := node.parseSlice(jval); err != nil {
 			return nil, err
 		} else {
 			node.array = rst.array
+			node.jType = jsonArray
+			node.link()
 			return node, nil
 		}
 	} else {
@@ -92,13 +110,32 @@ func JsonToString(value any) string {
 	return string(jsonVal)
 }
 
+// jsonPendingRaw 嵌套对象及数组的 rawString 占位，读取时按需生成，避免每个嵌套节点保存一份 JSON 字符串
+var jsonPendingRaw = "pending"
+
+// jsonUnmarshal 解析JSON数据，数值解析为 json.Number 以保留精度
+func jsonUnmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
 // JsonNode JSON 结构
 type JsonNode struct {
-	rawString *string              // JSON 原始字符串，解析的时候存储
-	object    map[string]*JsonNode // JSON 对象类型
-	array     []*JsonNode          // JSON 数组类型
-	value     any                  // JSON 字段值
-	jType     jsonType             // JSON 字段值类型
+	rawString *string                // JSON 原始字符串，解析的时候存储
+	object    map[string]*JsonNode   // JSON 对象类型
+	array     []*JsonNode            // JSON 数组类型
+	value     any                    // JSON 字段值
+	jType     jsonType               // JSON 字段值类型
+	parent    *JsonNode              // 父节点，修改时用于标记上级节点
+	dirty     bool                   // rawString 是否不可用：已修改或嵌套节点时，读取时按需生成
+	cache     atomic.Pointer[string] // dirty 时生成的 JSON 字符串缓存
 }
 
 // ToMap 转换为map对象  map[string]any
@@ -107,7 +144,7 @@ func (n *JsonNode) ToMap() map[string]any {
 		return MapEmpty[any]()
 	}
 	var m map[string]any
-	_ = json.Unmarshal([]byte(*n.rawString), &m)
+	_ = json.Unmarshal([]byte(n.text()), &m)
 	return m
 }
 
@@ -117,7 +154,7 @@ func (n *JsonNode) ToSlice() []map[string]any {
 		return SliceEmpty[map[string]any]()
 	}
 	var s []map[string]any
-	_ = json.Unmarshal([]byte(*n.rawString), &s)
+	_ = json.Unmarshal([]byte(n.text()), &s)
 	return s
 }
 
@@ -127,7 +164,7 @@ func (n *JsonNode) ToArray() []any {
 		return ArrayEmpty[any]()
 	}
 	var a []any
-	_ = json.Unmarshal([]byte(*n.rawString), &a)
+	_ = json.Unmarshal([]byte(n.text()), &a)
 	return a
 }
 
@@ -138,12 +175,12 @@ func (n *JsonNode) IsEmpty() bool {
 
 // IsArray 是否JSONArray类型
 func (n *JsonNode) IsArray() bool {
-	return n != nil && n.rawString != nil && *n.rawString != "" && n.object == nil && n.array != nil
+	return n != nil && n.jType == jsonArray
 }
 
 // IsObject 是否JSONObject 类型
 func (n *JsonNode) IsObject() bool {
-	return n != nil && n.rawString != nil && *n.rawString != "" && n.object != nil && n.array == nil
+	return n != nil && n.jType == jsonObject
 }
 
 // Size 长度，对象类型：字段个数，数组类型：数组长度
@@ -188,7 +225,7 @@ func (n *JsonNode) Name(key string) *JsonNode {
 
 // Index 获取JSON数组指定索引的值
 func (n *JsonNode) Index(index int) *JsonNode {
-	if n.IsArray() && !n.IsEmpty() && index < n.Size() {
+	if n.IsArray() && index >= 0 && index < n.Size() {
 		return n.array[index]
 	}
 	return nil
@@ -199,77 +236,290 @@ func (n *JsonNode) String() string {
 	if n == nil || n.rawString == nil {
 		return ""
 	}
-	return *n.rawString
+	return n.text()
 }
 
-// Int64 获取JSON字段指定的值内容，int64类型
+// Int64 获取JSON字段指定的值内容，int64类型，整数按原始文本精确解析，小数截断取整，值非法时 panic
 func (n *JsonNode) Int64() int64 {
 	if n == nil || n.rawString == nil {
 		return 0
 	}
-	switch n.jType {
-	case jsonString:
-		if val, err := strconv.ParseFloat(n.value.(string), 64); err == nil {
-			return int64(val)
-		} else {
-			panic(errors.New("invalid number value"))
-		}
-	case jsonNumber:
-		return int64(n.value.(float64))
-	case jsonBoolean:
-		if n.value.(bool) {
-			return 1
-		} else {
-			return 0
+	return jsonMust(n.Int64E())
+}
+
+// Int64E 获取JSON字段指定的值内容，int64类型，值为 null 或不存在时返回 ErrJsonNull，值非法时返回 ErrJsonInvalidNumber
+func (n *JsonNode) Int64E() (int64, error) {
+	num, err := n.numberText()
+	if err != nil {
+		return 0, err
+	}
+	if val, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return val, nil
+	}
+	if val, err := strconv.ParseFloat(num, 64); err == nil && val >= math.MinInt64 && val < math.MaxInt64 {
+		return int64(val), nil
+	}
+	return 0, fmt.Errorf("%w: %s out of int64 range", ErrJsonInvalidNumber, num)
+}
+
+// Int64Or 获取JSON字段指定的值内容，int64类型，值为 null、不存在或非法时返回 def
+func (n *JsonNode) Int64Or(def int64) int64 {
+	if val, err := n.Int64E(); err == nil {
+		return val
+	}
+	return def
+}
+
+// Uint64 获取JSON字段指定的值内容，uint64类型，整数按原始文本精确解析，小数截断取整，值非法时 panic
+func (n *JsonNode) Uint64() uint64 {
+	if n == nil || n.rawString == nil {
+		return 0
+	}
+	return jsonMust(n.Uint64E())
+}
+
+// Uint64E 获取JSON字段指定的值内容，uint64类型，值为 null 或不存在时返回 ErrJsonNull，值非法时返回 ErrJsonInvalidNumber
+func (n *JsonNode) Uint64E() (uint64, error) {
+	num, err := n.numberText()
+	if err != nil {
+		return 0, err
+	}
+	if val, err := strconv.ParseUint(num, 10, 64); err == nil {
+		return val, nil
+	}
+	if val, err := strconv.ParseFloat(num, 64); err == nil && val > -1 && val < math.MaxUint64 {
+		return uint64(val), nil
+	}
+	return 0, fmt.Errorf("%w: %s out of uint64 range", ErrJsonInvalidNumber, num)
+}
+
+// Uint64Or 获取JSON字段指定的值内容，uint64类型，值为 null、不存在或非法时返回 def
+func (n *JsonNode) Uint64Or(def uint64) uint64 {
+	if val, err := n.Uint64E(); err == nil {
+		return val
+	}
+	return def
+}
+
+// BigInt 获取JSON字段指定的值内容，*big.Int 类型，不限精度，小数截断取整，值非法时 panic
+func (n *JsonNode) BigInt() *big.Int {
+	if n == nil || n.rawString == nil {
+		return new(big.Int)
+	}
+	return jsonMust(n.BigIntE())
+}
+
+// BigIntE 获取JSON字段指定的值内容，*big.Int 类型，值为 null 或不存在时返回 ErrJsonNull，值非法时返回 ErrJsonInvalidNumber
+func (n *JsonNode) BigIntE() (*big.Int, error) {
+	num, err := n.numberText()
+	if err != nil {
+		return nil, err
+	}
+	if val, ok := new(big.Int).SetString(num, 10); ok {
+		return val, nil
+	}
+	if val, ok := new(big.Rat).SetString(num); ok {
+		return new(big.Int).Quo(val.Num(), val.Denom()), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrJsonInvalidNumber, num)
+}
+
+// Decimal 获取JSON字段指定的值内容，十进制字符串，不限精度，科学计数法将展开，例：1.5e3 返回 1500，值非法时 panic
+func (n *JsonNode) Decimal() string {
+	if n == nil || n.rawString == nil {
+		return "0"
+	}
+	return jsonMust(n.DecimalE())
+}
+
+// DecimalE 获取JSON字段指定的值内容，十进制字符串，值为 null 或不存在时返回 ErrJsonNull，值非法时返回 ErrJsonInvalidNumber
+func (n *JsonNode) DecimalE() (string, error) {
+	num, err := n.numberText()
+	if err != nil {
+		return "", err
+	}
+	val, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrJsonInvalidNumber, num)
+	}
+	return jsonRatDecimal(val), nil
+}
+
+// jsonRatDecimal 将有限小数格式化为十进制字符串
+func jsonRatDecimal(val *big.Rat) string {
+	if val.IsInt() {
+		return val.Num().String()
+	}
+	// 分母仅含因子 2 和 5，小数位数为两者个数的较大值
+	digits := map[int64]int{2: 0, 5: 0}
+	denom := new(big.Int).Set(val.Denom())
+	for factor := range digits {
+		f := big.NewInt(factor)
+		for new(big.Int).Mod(denom, f).Sign() == 0 {
+			denom.Quo(denom, f)
+			digits[factor]++
 		}
 	}
-	panic(errors.New("invalid number value"))
+	return val.FloatString(max(digits[2], digits[5]))
 }
 
-// Float64 获取JSON字段指定的值内容，float64类型
+// Float64 获取JSON字段指定的值内容，float64类型，值非法时 panic
 func (n *JsonNode) Float64() float64 {
 	if n == nil || n.rawString == nil {
 		return 0
 	}
+	return jsonMust(n.Float64E())
+}
+
+// Float64E 获取JSON字段指定的值内容，float64类型，值为 null 或不存在时返回 ErrJsonNull，值非法时返回 ErrJsonInvalidNumber
+func (n *JsonNode) Float64E() (float64, error) {
+	num, err := n.numberText()
+	if err != nil {
+		return 0, err
+	}
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrJsonInvalidNumber, num)
+	}
+	return val, nil
+}
+
+// Float64Or 获取JSON字段指定的值内容，float64类型，值为 null、不存在或非法时返回 def
+func (n *JsonNode) Float64Or(def float64) float64 {
+	if val, err := n.Float64E(); err == nil {
+		return val
+	}
+	return def
+}
+
+// Boolean 获取JSON字段指定的值内容，bool 类型，值非法时 panic
+func (n *JsonNode) Boolean() bool {
+	if n == nil || n.rawString == nil {
+		return false
+	}
+	return jsonMust(n.BooleanE())
+}
+
+// BooleanE 获取JSON字段指定的值内容，bool 类型，字符串支持 true、false（忽略大小写），数值大于等于 1 为 true，
+// 值为 null 或不存在时返回 ErrJsonNull，值非法时返回 ErrJsonInvalidBoolean
+func (n *JsonNode) BooleanE() (bool, error) {
+	if n == nil || n.rawString == nil {
+		return false, ErrJsonNull
+	}
 	switch n.jType {
 	case jsonString:
-		if val, err := strconv.ParseFloat(n.value.(string), 64); err == nil {
-			return val
-		} else {
-			panic(errors.New("invalid number value"))
+		if strings.EqualFold(n.value.(string), "true") {
+			return true, nil
+		} else if strings.EqualFold(n.value.(string), "false") {
+			return false, nil
 		}
 	case jsonNumber:
-		return n.value.(float64)
-	case jsonBoolean:
-		if n.value.(bool) {
-			return 1
-		} else {
-			return 0
+		if val, err := n.Float64E(); err == nil {
+			return val >= 1, nil
 		}
+	case jsonBoolean:
+		return n.value.(bool), nil
 	}
-	panic(errors.New("invalid number value"))
+	return false, fmt.Errorf("%w: %s", ErrJsonInvalidBoolean, n.text())
+}
 
+// BooleanOr 获取JSON字段指定的值内容，bool 类型，值为 null、不存在或非法时返回 def
+func (n *JsonNode) BooleanOr(def bool) bool {
+	if val, err := n.BooleanE(); err == nil {
+		return val
+	}
+	return def
 }
 
-// Boolean 获取JSON字段指定的值内容，bool 类型
-func (n *JsonNode) Boolean() bool {
+// StringE 获取JSON字段的字符串值，数值及布尔类型返回原始文本，
+// 值为 null 或不存在时返回 ErrJsonNull，对象及数组类型返回 ErrJsonTypeMismatch
+func (n *JsonNode) StringE() (string, error) {
 	if n == nil || n.rawString == nil {
-		return false
+		return "", ErrJsonNull
+	}
+	if n.IsObject() || n.IsArray() {
+		return "", fmt.Errorf("%w: expected string, got %s", ErrJsonTypeMismatch, n.text())
+	}
+	return *n.rawString, nil
+}
+
+// StringOr 获取JSON字段的字符串值，值为 null、不存在、对象或数组时返回 def
+func (n *JsonNode) StringOr(def string) string {
+	if val, err := n.StringE(); err == nil {
+		return val
+	}
+	return def
+}
+
+// TimeE 获取JSON字段的时间值，字符串按 layouts 依次以本地时区解析，
+// 未指定时依次尝试 RFC3339、2006-01-02 15:04:05、2006-01-02；
+// 数值按 Unix 时间戳解析，绝对值不小于 1e12 时视为毫秒，否则视为秒
+func (n *JsonNode) TimeE(layouts ...string) (time.Time, error) {
+	if n == nil || n.rawString == nil {
+		return time.Time{}, ErrJsonNull
 	}
 	switch n.jType {
+	case jsonNumber:
+		val, err := n.Int64E()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if val >= 1e12 || val <= -1e12 {
+			return time.UnixMilli(val), nil
+		}
+		return time.Unix(val, 0), nil
 	case jsonString:
-		if strings.EqualFold(strings.ToLower(n.value.(string)), "true") {
-			return true
-		} else if strings.EqualFold(strings.ToLower(n.value.(string)), "false") {
-			return false
+		if len(layouts) == 0 {
+			layouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+		}
+		value := strings.TrimSpace(n.value.(string))
+		for _, layout := range layouts {
+			if val, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+				return val, nil
+			}
 		}
-		panic(errors.New("invalid boolean value"))
+		return time.Time{}, fmt.Errorf("%w: %q", ErrJsonInvalidTime, value)
+	}
+	return time.Time{}, fmt.Errorf("%w: %s", ErrJsonInvalidTime, n.text())
+}
+
+// TimeOr 获取JSON字段的时间值，值为 null、不存在或非法时返回 def，layouts 同 TimeE
+func (n *JsonNode) TimeOr(def time.Time, layouts ...string) time.Time {
+	if val, err := n.TimeE(layouts...); err == nil {
+		return val
+	}
+	return def
+}
+
+// numberText 获取数值文本：数值类型为原始文本，字符串类型为去除空白后的内容，布尔类型为 1 或 0
+func (n *JsonNode) numberText() (string, error) {
+	if n == nil || n.rawString == nil {
+		return "", ErrJsonNull
+	}
+	switch n.jType {
 	case jsonNumber:
-		return int64(n.value.(float64)) > 0
+		return *n.rawString, nil
+	case jsonString:
+		num := strings.TrimSpace(n.value.(string))
+		if _, err := strconv.ParseFloat(num, 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+			return "", fmt.Errorf("%w: %q", ErrJsonInvalidNumber, n.value)
+		}
+		return num, nil
 	case jsonBoolean:
-		return n.value.(bool)
+		if n.value.(bool) {
+			return "1", nil
+		}
+		return "0", nil
 	}
-	panic(errors.New("invalid boolean value"))
+	return "", fmt.Errorf("%w: %s", ErrJsonInvalidNumber, n.text())
+}
+
+// jsonMust 返回值，错误时 panic
+func jsonMust[T any](val T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return val
 }
 
 // 从map解析为JSON对象
@@ -315,10 +565,15 @@ func (n *JsonNode) convert(val any) (*JsonNode, error) {
 			node.jType = jsonString
 			node.value = val
 			node.rawString = &raw
+		case json.Number:
+			raw := val.(json.Number).String()
+			node.jType = jsonNumber
+			node.value = val
+			node.rawString = &raw
 		case float64:
 			raw := strconv.FormatFloat(val.(float64), 'f', -1, 64)
 			node.jType = jsonNumber
-			node.value = val
+			node.value = json.Number(raw)
 			node.rawString = &raw
 		case bool:
 			raw := strconv.FormatBool(val.(bool))
@@ -329,21 +584,49 @@ func (n *JsonNode) convert(val any) (*JsonNode, error) {
 			if now, err := n.parseSlice(val.([]any)); err != nil {
 				return nil, err
 			} else {
-				raw := JsonToString(val)
 				node.jType = jsonArray
 				node.array = now.array
-				node.rawString = &raw
+				node.rawString = &jsonPendingRaw
+				node.dirty = true
+				node.link()
 			}
 		case map[string]any:
 			if now, err := n.parseMap(val.(map[string]any)); err != nil {
 				return nil, err
 			} else {
-				raw := JsonToString(val)
 				node.jType = jsonObject
 				node.object = now.object
-				node.rawString = &raw
+				node.rawString = &jsonPendingRaw
+				node.dirty = true
+				node.link()
 			}
 		}
 	}
 	return node, nil
 }
+
+// 设置子节点的父节点
+func (n *JsonNode) link() {
+	for _, child := range n.object {
+		child.parent = n
+	}
+	for _, child := range n.array {
+		child.parent = n
+	}
+}
+
+// 获取 JSON 原始字符串，已修改或嵌套节点时按需生成并缓存，与 JsonToString 一致转义 HTML 字符，
+// 缓存以原子操作读写，只读访问可并发进行，修改节点时由 touch 清除当前节点及上级节点的缓存
+func (n *JsonNode) text() string {
+	if !n.dirty {
+		return *n.rawString
+	}
+	if cached := n.cache.Load(); cached != nil {
+		return *cached
+	}
+	var buf bytes.Buffer
+	json.HTMLEscape(&buf, n.Marshal())
+	text := buf.String()
+	n.cache.Store(&text)
+	return text
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestJsonNodeNestedText(t *testing.T) {
+	node, err := JsonFromStringE(`{"a":{"html":"<b>&</b>","n":12345678901234567890,"list":[1,{"x":true}]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 嵌套节点的文本与 JsonToString 一致：键排序、转义 HTML 字符、数值不丢失精度
+	var val any
+	if err = jsonUnmarshal([]byte(`{"html":"<b>&</b>","n":12345678901234567890,"list":[1,{"x":true}]}`), &val); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := node.Name("a").String(), JsonToString(val); got != want {
+		t.Fatalf("String() = %s, want %s", got, want)
+	}
+	if got := node.Name("a").Name("list").String(); got != `[1,{"x":true}]` {
+		t.Fatalf("list String() = %s", got)
+	}
+}
+
+func TestJsonNodeTextCacheInvalidation(t *testing.T) {
+	node, err := JsonFromStringE(`{"a":{"b":{"c":1}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := node.Name("a"), node.Name("a").Name("b")
+	if a.String() != `{"b":{"c":1}}` || b.String() != `{"c":1}` {
+		t.Fatalf("before: %s %s", a, b)
+	}
+	if err = b.Set("c", 2); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != `{"b":{"c":2}}` || b.String() != `{"c":2}` || node.String() != `{"a":{"b":{"c":2}}}` {
+		t.Fatalf("after Set: %s %s %s", node, a, b)
+	}
+	if err = json.Unmarshal([]byte(`{"d":3}`), b); err != nil {
+		t.Fatal(err)
+	}
+	if a.String() != `{"b":{"d":3}}` || node.String() != `{"a":{"b":{"d":3}}}` {
+		t.Fatalf("after UnmarshalJSON: %s %s", node, a)
+	}
+	if m := a.ToMap(); len(m) != 1 || m["b"] == nil {
+		t.Fatalf("ToMap() = %v", m)
+	}
+}
+
+// 只读访问可并发进行，需配合 -race 运行
+func TestJsonNodeConcurrentRead(t *testing.T) {
+	node, err := JsonFromStringE(`{"a":{"b":[1,2,{"c":"d"}]},"e":[{"f":1}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = node.Name("a").String()
+				_ = node.Name("a").Name("b").ToArray()
+				_ = node.Name("e").ToSlice()
+			}
+		}()
+	}
+	wg.Wait()
+}
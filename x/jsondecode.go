@@ -0,0 +1,559 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"cmp"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JsonDecodeError 解码错误，携带错误值的 JSON 路径
+type JsonDecodeError struct {
+	Path string // JSON 路径，例：$.items[0].price
+	Err  error  // 原始错误
+}
+
+func (e *JsonDecodeError) Error() string {
+	return fmt.Sprintf("[x.JsonDecode] %s: %s", e.Path, e.Err.Error())
+}
+
+func (e *JsonDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// JsonDecode 将节点解码为 T 类型，支持结构体（json 标签）、切片、数组、映射、指针、time.Time、*JsonNode
+// 及实现 json.Unmarshaler 的类型（例：t.Int64、t.String）。
+// 数值、布尔及字符串之间按 Int64E、BooleanE、StringE 等规则宽松转换，any 类型中的数值解码为 json.Number 以保留精度，
+// 结构体字段的可见性及 ,string 标签选项与 encoding/json 一致，
+// 解码遇到非法值时继续解码其余字段，全部错误以 errors.Join 合并返回，每个错误均为 *JsonDecodeError
+func JsonDecode[T any](node *JsonNode) (T, error) {
+	var result T
+	var errs []error
+	jsonDecodeValue(node, reflect.ValueOf(&result).Elem(), "$", &errs)
+	return result, errors.Join(errs...)
+}
+
+var (
+	jsonNodeType        = reflect.TypeFor[*JsonNode]()
+	jsonNumberType      = reflect.TypeFor[json.Number]()
+	jsonTimeType        = reflect.TypeFor[time.Time]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	jsonTextType        = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// jsonDecodeValue 解码节点到 v，错误追加到 errs
+func jsonDecodeValue(node *JsonNode, v reflect.Value, path string, errs *[]error) {
+	fail := func(err error) {
+		*errs = append(*errs, &JsonDecodeError{Path: path, Err: err})
+	}
+	null := node == nil || node.rawString == nil
+
+	switch v.Type() {
+	case jsonNodeType:
+		if null {
+			v.SetZero()
+		} else if clone, err := JsonValueOf(node); err != nil {
+			fail(err)
+		} else {
+			v.Set(reflect.ValueOf(clone))
+		}
+		return
+	case jsonTimeType:
+		if !null {
+			if val, err := node.TimeE(); err != nil {
+				fail(err)
+			} else {
+				v.Set(reflect.ValueOf(val))
+			}
+		}
+		return
+	case jsonNumberType:
+		if !null {
+			if num, err := node.numberText(); err != nil {
+				fail(err)
+			} else {
+				v.SetString(num)
+			}
+		}
+		return
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if null {
+			v.SetZero()
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		jsonDecodeValue(node, v.Elem(), path, errs)
+		return
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(jsonUnmarshalerType) {
+		data := []byte("null")
+		if !null {
+			data = node.Marshal()
+		}
+		if err := v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+			fail(err)
+		}
+		return
+	}
+	if null {
+		return
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(jsonTextType) && node.jType == jsonString {
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(node.value.(string))); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			fail(fmt.Errorf("%w: cannot decode into %s", ErrJsonTypeMismatch, v.Type()))
+			return
+		}
+		var val any
+		if err := jsonUnmarshal(node.Marshal(), &val); err != nil {
+			fail(err)
+			return
+		}
+		v.Set(reflect.ValueOf(&val).Elem())
+	case reflect.Struct:
+		if !node.IsObject() {
+			fail(fmt.Errorf("%w: expected object for %s, got %s", ErrJsonTypeMismatch, v.Type(), node.text()))
+			return
+		}
+		jsonDecodeStruct(node, v, path, errs)
+	case reflect.Map:
+		if !node.IsObject() {
+			fail(fmt.Errorf("%w: expected object for %s, got %s", ErrJsonTypeMismatch, v.Type(), node.text()))
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), node.Size()))
+		}
+		for _, key := range node.Keys() {
+			kv := reflect.New(v.Type().Key()).Elem()
+			if err := jsonDecodeMapKey(key, kv); err != nil {
+				fail(err)
+				continue
+			}
+			ev := reflect.New(v.Type().Elem()).Elem()
+			jsonDecodeValue(node.object[key], ev, jsonPathChild(path, key), errs)
+			v.SetMapIndex(kv, ev)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && node.jType == jsonString {
+			if data, err := base64.StdEncoding.DecodeString(node.value.(string)); err != nil {
+				fail(err)
+			} else {
+				v.SetBytes(data)
+			}
+			return
+		}
+		if !node.IsArray() {
+			fail(fmt.Errorf("%w: expected array for %s, got %s", ErrJsonTypeMismatch, v.Type(), node.text()))
+			return
+		}
+		v.Set(reflect.MakeSlice(v.Type(), len(node.array), len(node.array)))
+		for i, child := range node.array {
+			jsonDecodeValue(child, v.Index(i), path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case reflect.Array:
+		if !node.IsArray() {
+			fail(fmt.Errorf("%w: expected array for %s, got %s", ErrJsonTypeMismatch, v.Type(), node.text()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			if i < len(node.array) {
+				jsonDecodeValue(node.array[i], v.Index(i), path+"["+strconv.Itoa(i)+"]", errs)
+			} else {
+				v.Index(i).SetZero()
+			}
+		}
+	case reflect.String:
+		if val, err := node.StringE(); err != nil {
+			fail(err)
+		} else {
+			v.SetString(val)
+		}
+	case reflect.Bool:
+		if val, err := node.BooleanE(); err != nil {
+			fail(err)
+		} else {
+			v.SetBool(val)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val, err := node.Int64E(); err != nil {
+			fail(err)
+		} else if v.OverflowInt(val) {
+			fail(fmt.Errorf("%w: %d overflows %s", ErrJsonInvalidNumber, val, v.Type()))
+		} else {
+			v.SetInt(val)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val, err := node.Uint64E(); err != nil {
+			fail(err)
+		} else if v.OverflowUint(val) {
+			fail(fmt.Errorf("%w: %d overflows %s", ErrJsonInvalidNumber, val, v.Type()))
+		} else {
+			v.SetUint(val)
+		}
+	case reflect.Float32, reflect.Float64:
+		if val, err := node.Float64E(); err != nil {
+			fail(err)
+		} else if v.OverflowFloat(val) {
+			fail(fmt.Errorf("%w: %v overflows %s", ErrJsonInvalidNumber, val, v.Type()))
+		} else {
+			v.SetFloat(val)
+		}
+	default:
+		fail(fmt.Errorf("%w: cannot decode into %s", ErrJsonTypeMismatch, v.Type()))
+	}
+}
+
+// jsonDecodeStruct 按 json 标签解码结构体字段，字段名称优先精确匹配，其次忽略大小写匹配，
+// 多个键忽略大小写匹配时取文档中的第一个，节点已修改无法获取文档顺序时取字典序最小的键。
+// 嵌入结构体字段的可见性与 encoding/json 一致，见 jsonStructFields
+func jsonDecodeStruct(node *JsonNode, v reflect.Value, path string, errs *[]error) {
+	fields := jsonStructFields(v.Type())
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f.name] = true
+	}
+	for _, f := range fields {
+		child, ok := node.object[f.name]
+		if !ok {
+			child, ok = jsonFoldField(node, f.name, names)
+		}
+		if !ok {
+			continue
+		}
+		childPath := jsonPathChild(path, f.name)
+		fv, err := jsonStructField(v, f.index)
+		if err != nil {
+			*errs = append(*errs, &JsonDecodeError{Path: childPath, Err: err})
+			continue
+		}
+		if f.quoted {
+			jsonDecodeQuoted(child, fv, childPath, errs)
+		} else {
+			jsonDecodeValue(child, fv, childPath, errs)
+		}
+	}
+}
+
+// jsonDecodeField 结构体字段解码信息
+type jsonDecodeField struct {
+	name   string // JSON 字段名称
+	index  []int  // 字段索引路径，嵌入结构体字段包含多级索引
+	tagged bool   // 是否由 json 标签指定名称
+	quoted bool   // 是否带 ,string 选项
+}
+
+// jsonFieldCache 结构体类型的字段解码信息缓存
+var jsonFieldCache sync.Map // map[reflect.Type][]jsonDecodeField
+
+// jsonStructFields 按 encoding/json 规则收集结构体字段，包括嵌入结构体的字段：
+// 同名字段取嵌入层级最浅的，同一层级时取由 json 标签指定名称的，仍有多个时视为冲突全部忽略
+func jsonStructFields(t reflect.Type) []jsonDecodeField {
+	if cached, ok := jsonFieldCache.Load(t); ok {
+		return cached.([]jsonDecodeField)
+	}
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []jsonDecodeField
+	visited := make(map[reflect.Type]bool)
+	for next := []embedded{{typ: t}}; len(next) > 0; {
+		current := next
+		next = nil
+		// 同一层级重复嵌入的类型均参与收集，以便其字段互相冲突
+		var level []reflect.Type
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			level = append(level, e.typ)
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if !sf.IsExported() && (!sf.Anonymous || ft.Kind() != reflect.Struct) {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(slices.Clone(e.index), i)
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{typ: ft, index: index})
+					continue
+				}
+				f := jsonDecodeField{name: name, index: index, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				if slices.Contains(strings.Split(opts, ","), "string") {
+					switch ft.Kind() {
+					case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+						reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+						reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+						f.quoted = true
+					}
+				}
+				fields = append(fields, f)
+			}
+		}
+		for _, typ := range level {
+			visited[typ] = true
+		}
+	}
+
+	slices.SortStableFunc(fields, func(a, b jsonDecodeField) int {
+		return cmp.Or(cmp.Compare(a.name, b.name), cmp.Compare(len(a.index), len(b.index)))
+	})
+	out := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if f, ok := jsonDominantField(fields[i:j]); ok {
+			out = append(out, f)
+		}
+		i = j
+	}
+	slices.SortFunc(out, func(a, b jsonDecodeField) int {
+		return slices.Compare(a.index, b.index)
+	})
+	cached, _ := jsonFieldCache.LoadOrStore(t, out)
+	return cached.([]jsonDecodeField)
+}
+
+// jsonDominantField 从同名字段（已按嵌入层级排序）中选取生效的字段
+func jsonDominantField(fields []jsonDecodeField) (jsonDecodeField, bool) {
+	depth := len(fields[0].index)
+	var dominant []jsonDecodeField
+	for _, f := range fields {
+		if len(f.index) > depth {
+			break
+		}
+		dominant = append(dominant, f)
+	}
+	if len(dominant) > 1 {
+		dominant = slices.DeleteFunc(dominant, func(f jsonDecodeField) bool { return !f.tagged })
+	}
+	if len(dominant) != 1 {
+		return jsonDecodeField{}, false
+	}
+	return dominant[0], true
+}
+
+// jsonStructField 按索引路径获取结构体字段，途经的 nil 嵌入结构体指针将被分配
+func jsonStructField(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// jsonDecodeQuoted 解码带 ,string 选项的字段：值须为 JSON 字符串，其内容按 JSON 字面量解码，
+// 例：数值字段 "123"、字符串字段 "\"abc\""，与 encoding/json 一致
+func jsonDecodeQuoted(node *JsonNode, v reflect.Value, path string, errs *[]error) {
+	if node == nil || node.rawString == nil {
+		jsonDecodeValue(node, v, path, errs)
+		return
+	}
+	fail := func(err error) {
+		*errs = append(*errs, &JsonDecodeError{Path: path, Err: err})
+	}
+	if node.jType != jsonString {
+		fail(fmt.Errorf("%w: invalid use of ,string struct tag, trying to decode unquoted value %s into %s", ErrJsonTypeMismatch, node.text(), v.Type()))
+		return
+	}
+	inner := new(JsonNode)
+	err := inner.UnmarshalJSON([]byte(node.value.(string)))
+	if err == nil && inner.rawString != nil {
+		kind := v.Kind()
+		if kind == reflect.Pointer {
+			kind = v.Type().Elem().Kind()
+		}
+		if inner.IsObject() || inner.IsArray() || (kind == reflect.String) != (inner.jType == jsonString) {
+			err = ErrJsonTypeMismatch
+		}
+	}
+	if err != nil {
+		fail(fmt.Errorf("%w: invalid use of ,string struct tag, trying to decode %s into %s", ErrJsonTypeMismatch, node.text(), v.Type()))
+		return
+	}
+	jsonDecodeValue(inner, v, path, errs)
+}
+
+// jsonFoldField 忽略大小写查找对象字段，与其他字段名称精确匹配的键除外，
+// 多个键匹配时取文档中的第一个，无法获取文档顺序时取字典序最小的键
+func jsonFoldField(node *JsonNode, name string, names map[string]bool) (*JsonNode, bool) {
+	var keys []string
+	for key := range node.object {
+		if strings.EqualFold(key, name) && !names[key] {
+			keys = append(keys, key)
+		}
+	}
+	switch len(keys) {
+	case 0:
+		return nil, false
+	case 1:
+		return node.object[keys[0]], true
+	}
+	for _, key := range jsonDocumentKeys(node) {
+		if slices.Contains(keys, key) {
+			return node.object[key], true
+		}
+	}
+	slices.Sort(keys)
+	return node.object[keys[0]], true
+}
+
+// jsonDocumentKeys 从根节点的原始 JSON 字符串中获取对象键的文档顺序，根节点已修改或非解析所得时返回 nil
+func jsonDocumentKeys(node *JsonNode) []string {
+	root := node.root()
+	if root.dirty || root.rawString == nil {
+		return nil
+	}
+	// 自下而上记录节点在父节点中的位置
+	var steps []any
+	for n := node; n.parent != nil; n = n.parent {
+		if n.parent.IsArray() {
+			steps = append(steps, slices.Index(n.parent.array, n))
+			continue
+		}
+		for key, child := range n.parent.object {
+			if child == n {
+				steps = append(steps, key)
+				break
+			}
+		}
+	}
+	raw := json.RawMessage(*root.rawString)
+	for i := len(steps) - 1; i >= 0; i-- {
+		found := false
+		err := jsonScanRaw(raw, func(key string, index int, value json.RawMessage) {
+			// 重复的键以最后一个为准，与解析结果一致
+			if k, ok := steps[i].(string); (ok && k == key) || (!ok && steps[i] == index) {
+				raw, found = value, true
+			}
+		})
+		if err != nil || !found {
+			return nil
+		}
+	}
+	var keys []string
+	if err := jsonScanRaw(raw, func(key string, _ int, _ json.RawMessage) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// jsonScanRaw 按文档顺序遍历 JSON 对象或数组的直接子元素，对象回调 key，数组回调 index
+func jsonScanRaw(raw json.RawMessage, fn func(key string, index int, value json.RawMessage)) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	object := tok == json.Delim('{')
+	if !object && tok != json.Delim('[') {
+		return ErrJsonTypeMismatch
+	}
+	for index := 0; dec.More(); index++ {
+		var key string
+		if object {
+			if tok, err = dec.Token(); err != nil {
+				return err
+			}
+			key, _ = tok.(string)
+		}
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return err
+		}
+		fn(key, index, value)
+	}
+	return nil
+}
+
+// jsonDecodeMapKey 解码映射键，支持字符串、整数及实现 encoding.TextUnmarshaler 的类型
+func jsonDecodeMapKey(key string, kv reflect.Value) error {
+	if kv.Addr().Type().Implements(jsonTextType) {
+		return kv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key))
+	}
+	switch kv.Kind() {
+	case reflect.String:
+		kv.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, err := strconv.ParseInt(key, 10, kv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: map key %q", ErrJsonInvalidNumber, key)
+		}
+		kv.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		val, err := strconv.ParseUint(key, 10, kv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: map key %q", ErrJsonInvalidNumber, key)
+		}
+		kv.SetUint(val)
+	default:
+		return fmt.Errorf("%w: unsupported map key type %s", ErrJsonTypeMismatch, kv.Type())
+	}
+	return nil
+}
+
+// jsonPathChild 生成子节点的 JSON 路径
+func jsonPathChild(path, key string) string {
+	for _, c := range key {
+		if !(c == '_' || c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c > 127) {
+			return path + "[" + strconv.Quote(key) + "]"
+		}
+	}
+	if key == "" {
+		return path + `[""]`
+	}
+	return path + "." + key
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// 字段可见性须与 encoding/json 一致：外层字段优先，同层级有标签者优先，同层级冲突时忽略
+func TestJsonDecodeEmbeddedFields(t *testing.T) {
+	type Base struct {
+		ID      int    `json:"id"`
+		Name    string `json:"name"`
+		Created string
+	}
+	type Audit struct {
+		Name    string `json:"name"`
+		Created string
+		Tagged  string `json:"tagged"`
+		Other   string
+	}
+	type Other struct {
+		Tagged string
+		Other  string
+	}
+	type Inner struct {
+		Deep string `json:"deep"`
+	}
+	type Ptr struct {
+		*Inner
+	}
+	type Entity struct {
+		Base
+		*Audit
+		Other
+		Name  string `json:"name"`
+		Outer Ptr
+	}
+	data := `{"id":1,"name":"outer","Created":"c","tagged":"t","Other":"o","Outer":{"deep":"d"}}`
+	node, err := JsonFromStringE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := JsonDecode[Entity](node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want Entity
+	if err = json.Unmarshal([]byte(data), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("JsonDecode = %+v, want %+v", got, want)
+	}
+	if got.Base.Name != "" || got.Audit.Name != "" || got.Base.Created != "" || got.Audit.Created != "" ||
+		got.Audit.Other != "" || got.Other.Other != "" {
+		t.Fatalf("shadowed or conflicting fields decoded: %+v %+v %+v", got.Base, got.Audit, got.Other)
+	}
+	if got.ID != 1 || got.Name != "outer" || got.Audit.Tagged != "t" || got.Other.Tagged != "" || got.Outer.Deep != "d" {
+		t.Fatalf("dominant fields not decoded: %+v %+v", got, got.Audit)
+	}
+}
+
+type decodeTestInner struct {
+	Deep string `json:"deep"`
+}
+
+// 未导出的嵌入结构体指针无法分配，与 encoding/json 一致返回错误
+func TestJsonDecodeUnexportedEmbeddedPointer(t *testing.T) {
+	type outer struct {
+		*decodeTestInner
+		Name string `json:"name"`
+	}
+	got, err := JsonDecode[outer](JsonFromString(`{"deep":"d","name":"n"}`, false))
+	var de *JsonDecodeError
+	if !errors.As(err, &de) || de.Path != "$.deep" || got.Name != "n" {
+		t.Fatalf("JsonDecode = %+v, %v", got, err)
+	}
+	if json.Unmarshal([]byte(`{"deep":"d"}`), new(outer)) == nil {
+		t.Fatal("encoding/json accepted unexported embedded pointer")
+	}
+}
+
+func TestJsonDecodeFoldPrefersExactField(t *testing.T) {
+	type item struct {
+		ID int
+		Id int
+	}
+	got, err := JsonDecode[item](JsonFromString(`{"ID":1}`, false))
+	if err != nil || got.ID != 1 || got.Id != 0 {
+		t.Fatalf("JsonDecode = %+v, %v", got, err)
+	}
+}
+
+func TestJsonDecodeStringOption(t *testing.T) {
+	type order struct {
+		ID     int64    `json:"id,string"`
+		Price  *float64 `json:"price,string"`
+		Paid   bool     `json:"paid,string"`
+		Remark string   `json:"remark,string"`
+		Tags   []string `json:"tags,string"`
+	}
+	data := `{"id":"9007199254740993","price":"1.5","paid":"true","remark":"\"ok\"","tags":["a"]}`
+	got, err := JsonDecode[order](JsonFromString(data, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want order
+	if err = json.Unmarshal([]byte(data), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("JsonDecode = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{`{"id":1}`, `{"id":"abc"}`, `{"id":"\"1\""}`, `{"remark":"ok"}`, `{"paid":"[true]"}`} {
+		_, err = JsonDecode[order](JsonFromString(bad, false))
+		var de *JsonDecodeError
+		if !errors.As(err, &de) || !errors.Is(err, ErrJsonTypeMismatch) {
+			t.Errorf("JsonDecode(%s) err = %v", bad, err)
+		}
+		if json.Unmarshal([]byte(bad), new(order)) == nil {
+			t.Errorf("encoding/json accepted %s", bad)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrJsonTypeMismatch    = errors.New("x: json node type mismatch") // 节点类型不匹配，例：对数组执行 Set
+	ErrJsonIndexOutOfRange = errors.New("x: json index out of range") // 数组索引越界
+)
+
+// JsonNewObject 创建空 JSON 对象 {}
+func JsonNewObject() *JsonNode {
+	raw := "{}"
+	return &JsonNode{rawString: &raw, object: make(map[string]*JsonNode), jType: jsonObject}
+}
+
+// JsonNewArray 创建空 JSON 数组 []
+func JsonNewArray() *JsonNode {
+	raw := "[]"
+	return &JsonNode{rawString: &raw, array: make([]*JsonNode, 0), jType: jsonArray}
+}
+
+// JsonValueOf 将任意值转换为 JSON 节点，*JsonNode 类型将被复制
+func JsonValueOf(value any) (*JsonNode, error) {
+	if node, ok := value.(*JsonNode); ok {
+		return node.Clone(), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var val any
+	if err = jsonUnmarshal(data, &val); err != nil {
+		return nil, err
+	}
+	return new(JsonNode).convert(val)
+}
+
+// Clone 深度复制节点，复制的节点没有父节点
+func (n *JsonNode) Clone() *JsonNode {
+	if n == nil || n.rawString == nil {
+		return &JsonNode{jType: jsonNull}
+	}
+	node := &JsonNode{rawString: n.rawString, value: n.value, jType: n.jType, dirty: n.dirty}
+	if n.object != nil {
+		node.object = make(map[string]*JsonNode, len(n.object))
+		for key, child := range n.object {
+			node.object[key] = child.Clone()
+		}
+	}
+	if n.array != nil {
+		node.array = make([]*JsonNode, len(n.array))
+		for i, child := range n.array {
+			node.array[i] = child.Clone()
+		}
+	}
+	node.link()
+	return node
+}
+
+// Set 设置 JSON 对象字段的值，value 可以为 *JsonNode 或任意可 JSON 编码的值
+func (n *JsonNode) Set(key string, value any) error {
+	if !n.IsObject() {
+		return fmt.Errorf("%w: set %q on non-object", ErrJsonTypeMismatch, key)
+	}
+	node, err := n.adopt(value)
+	if err != nil {
+		return err
+	}
+	if old := n.object[key]; old != nil {
+		old.parent = nil
+	}
+	n.object[key] = node
+	n.touch()
+	return nil
+}
+
+// SetIndex 设置 JSON 数组指定索引的值，索引支持负数（从末尾计数）
+func (n *JsonNode) SetIndex(index int, value any) error {
+	if !n.IsArray() {
+		return fmt.Errorf("%w: set index %d on non-array", ErrJsonTypeMismatch, index)
+	}
+	if index < 0 {
+		index += len(n.array)
+	}
+	if index < 0 || index >= len(n.array) {
+		return fmt.Errorf("%w: %d", ErrJsonIndexOutOfRange, index)
+	}
+	node, err := n.adopt(value)
+	if err != nil {
+		return err
+	}
+	n.array[index].parent = nil
+	n.array[index] = node
+	n.touch()
+	return nil
+}
+
+// SetPath 按路径设置值，例：data.items[0].price，路径中不存在的对象字段将自动创建，
+// 数组索引等于数组长度时追加到末尾
+func (n *JsonNode) SetPath(path string, value any) error {
+	sels, err := jsonPathSingular(path)
+	if err != nil {
+		return err
+	}
+	if len(sels) == 0 {
+		return fmt.Errorf("%w: empty path", ErrJsonPathSyntax)
+	}
+	node := n
+	for i, sel := range sels[:len(sels)-1] {
+		child := node.child(sel)
+		if child == nil {
+			if sels[i+1].kind == jsonSelIndex {
+				child = JsonNewArray()
+			} else {
+				child = JsonNewObject()
+			}
+			if err = node.setChild(sel, child); err != nil {
+				return err
+			}
+			child = node.child(sel)
+		}
+		node = child
+	}
+	return node.setChild(sels[len(sels)-1], value)
+}
+
+// Append 追加值到 JSON 数组末尾
+func (n *JsonNode) Append(values ...any) error {
+	if !n.IsArray() {
+		return fmt.Errorf("%w: append on non-array", ErrJsonTypeMismatch)
+	}
+	nodes := make([]*JsonNode, len(values))
+	for i, value := range values {
+		node, err := n.adopt(value)
+		if err != nil {
+			return err
+		}
+		nodes[i] = node
+	}
+	n.array = append(n.array, nodes...)
+	n.touch()
+	return nil
+}
+
+// Insert 在 JSON 数组指定索引处插入值，索引等于数组长度时追加到末尾
+func (n *JsonNode) Insert(index int, value any) error {
+	if !n.IsArray() {
+		return fmt.Errorf("%w: insert on non-array", ErrJsonTypeMismatch)
+	}
+	if index < 0 {
+		index += len(n.array)
+	}
+	if index < 0 || index > len(n.array) {
+		return fmt.Errorf("%w: %d", ErrJsonIndexOutOfRange, index)
+	}
+	node, err := n.adopt(value)
+	if err != nil {
+		return err
+	}
+	n.array = append(n.array, nil)
+	copy(n.array[index+1:], n.array[index:])
+	n.array[index] = node
+	n.touch()
+	return nil
+}
+
+// Delete 删除 JSON 对象指定字段，返回字段是否存在
+func (n *JsonNode) Delete(key string) bool {
+	if !n.IsObject() {
+		return false
+	}
+	old, ok := n.object[key]
+	if !ok {
+		return false
+	}
+	old.parent = nil
+	delete(n.object, key)
+	n.touch()
+	return true
+}
+
+// DeleteIndex 删除 JSON 数组指定索引的值，索引支持负数（从末尾计数），返回索引是否存在
+func (n *JsonNode) DeleteIndex(index int) bool {
+	if !n.IsArray() {
+		return false
+	}
+	if index < 0 {
+		index += len(n.array)
+	}
+	if index < 0 || index >= len(n.array) {
+		return false
+	}
+	n.array[index].parent = nil
+	n.array = append(n.array[:index], n.array[index+1:]...)
+	n.touch()
+	return true
+}
+
+// DeletePath 按路径删除值，路径不存在时返回 ErrJsonPathNotFound
+func (n *JsonNode) DeletePath(path string) error {
+	sels, err := jsonPathSingular(path)
+	if err != nil {
+		return err
+	}
+	if len(sels) == 0 {
+		return fmt.Errorf("%w: empty path", ErrJsonPathSyntax)
+	}
+	node := n
+	for _, sel := range sels[:len(sels)-1] {
+		if node = node.child(sel); node == nil {
+			return fmt.Errorf("%w: %s", ErrJsonPathNotFound, path)
+		}
+	}
+	last, ok := sels[len(sels)-1], false
+	if last.kind == jsonSelName {
+		ok = node.Delete(last.name)
+	} else {
+		ok = node.DeleteIndex(last.index)
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJsonPathNotFound, path)
+	}
+	return nil
+}
+
+// Marshal 序列化为 JSON 字符串，对象字段按名称排序，不转义 HTML 字符
+func (n *JsonNode) Marshal() []byte {
+	var buf bytes.Buffer
+	n.encode(&buf, "", "", 0)
+	return buf.Bytes()
+}
+
+// MarshalIndent 序列化为带缩进的 JSON 字符串，对象字段按名称排序，不转义 HTML 字符
+func (n *JsonNode) MarshalIndent(prefix, indent string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(prefix)
+	n.encode(&buf, prefix, indent, 0)
+	return buf.Bytes()
+}
+
+// MarshalJSON 实现 json.Marshaler 接口
+func (n *JsonNode) MarshalJSON() ([]byte, error) {
+	return n.Marshal(), nil
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口
+func (n *JsonNode) UnmarshalJSON(data []byte) error {
+	var val any
+	if err := jsonUnmarshal(data, &val); err != nil {
+		return err
+	}
+	node, err := new(JsonNode).convert(val)
+	if err != nil {
+		return err
+	}
+	n.rawString, n.object, n.array, n.value, n.jType, n.dirty = node.rawString, node.object, node.array, node.value, node.jType, node.dirty
+	n.cache.Store(nil)
+	n.link()
+	if n.parent != nil {
+		n.parent.touch()
+	}
+	return nil
+}
+
+// adopt 将值转换为当前节点的子节点
+func (n *JsonNode) adopt(value any) (*JsonNode, error) {
+	node, ok := value.(*JsonNode)
+	if !ok || node == nil || node.parent != nil || node == n.root() {
+		var err error
+		if node, err = JsonValueOf(value); err != nil {
+			return nil, err
+		}
+	}
+	node.parent = n
+	return node, nil
+}
+
+// setChild 按字段名称或数组索引设置子节点，数组索引等于数组长度时追加到末尾
+func (n *JsonNode) setChild(sel jsonPathSelector, value any) error {
+	if sel.kind == jsonSelName {
+		return n.Set(sel.name, value)
+	}
+	if n.IsArray() && sel.index == len(n.array) {
+		return n.Append(value)
+	}
+	return n.SetIndex(sel.index, value)
+}
+
+// root 获取根节点
+func (n *JsonNode) root() *JsonNode {
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+// touch 标记当前节点及上级节点已修改，并清除生成的 JSON 字符串缓存
+func (n *JsonNode) touch() {
+	for node := n; node != nil; node = node.parent {
+		node.dirty = true
+		node.cache.Store(nil)
+	}
+}
+
+// encode 序列化节点
+func (n *JsonNode) encode(buf *bytes.Buffer, prefix, indent string, depth int) {
+	newline := func(depth int) {
+		if indent != "" {
+			buf.WriteByte('\n')
+			buf.WriteString(prefix)
+			buf.WriteString(strings.Repeat(indent, depth))
+		}
+	}
+	switch {
+	case n == nil || n.rawString == nil:
+		buf.WriteString("null")
+	case n.IsObject():
+		if len(n.object) == 0 {
+			buf.WriteString("{}")
+			return
+		}
+		keys := n.Keys()
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			jsonQuote(buf, key)
+			buf.WriteByte(':')
+			if indent != "" {
+				buf.WriteByte(' ')
+			}
+			n.object[key].encode(buf, prefix, indent, depth+1)
+		}
+		newline(depth)
+		buf.WriteByte('}')
+	case n.IsArray():
+		if len(n.array) == 0 {
+			buf.WriteString("[]")
+			return
+		}
+		buf.WriteByte('[')
+		for i, child := range n.array {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			child.encode(buf, prefix, indent, depth+1)
+		}
+		newline(depth)
+		buf.WriteByte(']')
+	case n.jType == jsonString:
+		jsonQuote(buf, *n.rawString)
+	default:
+		buf.WriteString(*n.rawString)
+	}
+}
+
+// jsonQuote 写入带引号的 JSON 字符串，不转义 HTML 字符
+func jsonQuote(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	buf.Truncate(buf.Len() - 1)
+}
@@ -0,0 +1,387 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrJsonPatch = errors.New("x: json patch failed") // JSON Patch 执行失败
+
+// JsonPatchOp JSON Patch（RFC 6902）操作
+type JsonPatchOp struct {
+	Op    string          `json:"op"`              // 操作：add、remove、replace、move、copy、test
+	Path  string          `json:"path"`            // 目标 JSON Pointer，例：/items/0/price
+	From  string          `json:"from,omitempty"`  // 来源 JSON Pointer，仅 move、copy
+	Value json.RawMessage `json:"value,omitempty"` // 值，仅 add、replace、test，nil 表示缺少该字段，null 值为 []byte("null")
+}
+
+// JsonArrayStrategy JsonMerge 数组合并策略
+type JsonArrayStrategy int8
+
+const (
+	JsonArrayReplace JsonArrayStrategy = iota // 以 src 数组替换 dst 数组
+	JsonArrayAppend                           // 将 src 数组元素追加到 dst 数组末尾
+	JsonArrayUnion                            // 将 dst 数组中不存在的 src 数组元素追加到末尾
+	JsonArrayByIndex                          // 按索引逐个深度合并，src 较长时追加多出的元素
+)
+
+// JsonDiff 比较两个节点，返回将 a 转换为 b 的 JSON Patch（RFC 6902）操作列表，相同时返回空列表。
+// 对象字段按名称排序比较，数组按索引比较，多出的元素从末尾删除或追加
+func JsonDiff(a, b *JsonNode) []JsonPatchOp {
+	ops := make([]JsonPatchOp, 0)
+	jsonDiff(a, b, "", &ops)
+	return ops
+}
+
+// jsonDiff 递归比较节点
+func jsonDiff(a, b *JsonNode, ptr string, ops *[]JsonPatchOp) {
+	ka, kb := jsonSchemaType(a), jsonSchemaType(b)
+	switch {
+	case ka != kb:
+		*ops = append(*ops, JsonPatchOp{Op: "replace", Path: ptr, Value: jsonPatchValue(b)})
+	case ka == "object":
+		keys := a.Keys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := b.object[key]; !ok {
+				*ops = append(*ops, JsonPatchOp{Op: "remove", Path: ptr + "/" + jsonPointerEscape(key)})
+			}
+		}
+		keys = b.Keys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			p := ptr + "/" + jsonPointerEscape(key)
+			if av, ok := a.object[key]; ok {
+				jsonDiff(av, b.object[key], p, ops)
+			} else {
+				*ops = append(*ops, JsonPatchOp{Op: "add", Path: p, Value: jsonPatchValue(b.object[key])})
+			}
+		}
+	case ka == "array":
+		common := min(len(a.array), len(b.array))
+		for i := 0; i < common; i++ {
+			jsonDiff(a.array[i], b.array[i], ptr+"/"+strconv.Itoa(i), ops)
+		}
+		for i := len(a.array) - 1; i >= common; i-- {
+			*ops = append(*ops, JsonPatchOp{Op: "remove", Path: ptr + "/" + strconv.Itoa(i)})
+		}
+		for i := common; i < len(b.array); i++ {
+			*ops = append(*ops, JsonPatchOp{Op: "add", Path: ptr + "/" + strconv.Itoa(i), Value: jsonPatchValue(b.array[i])})
+		}
+	case !jsonEqual(a, b):
+		*ops = append(*ops, JsonPatchOp{Op: "replace", Path: ptr, Value: jsonPatchValue(b)})
+	}
+}
+
+// jsonPatchValue 序列化操作值
+func jsonPatchValue(n *JsonNode) json.RawMessage {
+	return n.Marshal()
+}
+
+// jsonPatchOpValue 解析 add、replace、test 操作的值，缺少 value 字段时返回错误
+func jsonPatchOpValue(op JsonPatchOp) (*JsonNode, error) {
+	if op.Value == nil {
+		return nil, errors.New("missing value")
+	}
+	var val JsonNode
+	if err := val.UnmarshalJSON(op.Value); err != nil {
+		return nil, err
+	}
+	return &val, nil
+}
+
+// JsonPatch 对节点副本执行 JSON Patch（RFC 6902）操作并返回结果，任一操作失败时返回 ErrJsonPatch 且不修改原节点
+func JsonPatch(doc *JsonNode, ops []JsonPatchOp) (*JsonNode, error) {
+	root := doc.Clone()
+	for i, op := range ops {
+		var err error
+		if root, err = jsonPatchApply(root, op); err != nil {
+			return nil, fmt.Errorf("%w: op %d (%s %s): %v", ErrJsonPatch, i, op.Op, op.Path, err)
+		}
+	}
+	return root, nil
+}
+
+// JsonPatchBytes 解析 JSON Patch 文档并对节点副本执行，例：[{"op":"replace","path":"/a","value":1}]
+func JsonPatchBytes(doc *JsonNode, patch []byte) (*JsonNode, error) {
+	var ops []JsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJsonPatch, err)
+	}
+	return JsonPatch(doc, ops)
+}
+
+// jsonPatchApply 执行单个操作，返回新的根节点
+func jsonPatchApply(root *JsonNode, op JsonPatchOp) (*JsonNode, error) {
+	switch op.Op {
+	case "add":
+		val, err := jsonPatchOpValue(op)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(root, op.Path, val)
+	case "remove":
+		_, err := jsonPointerRemove(root, op.Path)
+		return root, err
+	case "replace":
+		val, err := jsonPatchOpValue(op)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = jsonPointerGet(root, op.Path); err != nil {
+			return nil, err
+		}
+		if op.Path == "" {
+			return val, nil
+		}
+		if _, err = jsonPointerRemove(root, op.Path); err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(root, op.Path, val)
+	case "move":
+		if op.From == op.Path {
+			_, err := jsonPointerGet(root, op.From)
+			return root, err
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, errors.New("cannot move a value into one of its children")
+		}
+		val, err := jsonPointerRemove(root, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(root, op.Path, val)
+	case "copy":
+		val, err := jsonPointerGet(root, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(root, op.Path, val.Clone())
+	case "test":
+		want, err := jsonPatchOpValue(op)
+		if err != nil {
+			return nil, err
+		}
+		val, err := jsonPointerGet(root, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(val, want) {
+			return nil, fmt.Errorf("value %s does not match %s", val.Marshal(), want.Marshal())
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+// jsonPointerTokens 解析 JSON Pointer（RFC 6901）
+func jsonPointerTokens(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// jsonPointerIndex 解析数组索引，allowEnd 为 true 时允许 - 及等于数组长度的索引
+func jsonPointerIndex(n *JsonNode, token string, allowEnd bool) (int, error) {
+	size := len(n.array)
+	if token == "-" && allowEnd {
+		return size, nil
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if idx > size || (idx == size && !allowEnd) {
+		return 0, fmt.Errorf("%w: %d", ErrJsonIndexOutOfRange, idx)
+	}
+	return idx, nil
+}
+
+// jsonPointerGet 按 JSON Pointer 获取节点
+func jsonPointerGet(root *JsonNode, ptr string) (*JsonNode, error) {
+	tokens, err := jsonPointerTokens(ptr)
+	if err != nil {
+		return nil, err
+	}
+	node := root
+	for _, token := range tokens {
+		switch {
+		case node.IsObject():
+			child, ok := node.object[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrJsonPathNotFound, ptr)
+			}
+			node = child
+		case node.IsArray():
+			idx, err := jsonPointerIndex(node, token, false)
+			if err != nil {
+				return nil, err
+			}
+			node = node.array[idx]
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrJsonPathNotFound, ptr)
+		}
+	}
+	return node, nil
+}
+
+// jsonPointerParent 获取 JSON Pointer 的父节点及最后一个路径片段
+func jsonPointerParent(root *JsonNode, ptr string) (*JsonNode, string, error) {
+	tokens, err := jsonPointerTokens(ptr)
+	if err != nil {
+		return nil, "", err
+	}
+	last := len(tokens) - 1
+	parent, err := jsonPointerGet(root, ptr[:len(ptr)-len(jsonPointerEscape(tokens[last]))-1])
+	if err != nil {
+		return nil, "", err
+	}
+	return parent, tokens[last], nil
+}
+
+// jsonPointerAdd 按 JSON Pointer 添加值，数组插入到指定索引，返回新的根节点
+func jsonPointerAdd(root *JsonNode, ptr string, val *JsonNode) (*JsonNode, error) {
+	if ptr == "" {
+		return val, nil
+	}
+	parent, token, err := jsonPointerParent(root, ptr)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case parent.IsObject():
+		return root, parent.Set(token, val)
+	case parent.IsArray():
+		idx, err := jsonPointerIndex(parent, token, true)
+		if err != nil {
+			return nil, err
+		}
+		return root, parent.Insert(idx, val)
+	}
+	return nil, fmt.Errorf("%w: parent of %s is not a container", ErrJsonTypeMismatch, ptr)
+}
+
+// jsonPointerRemove 按 JSON Pointer 删除值，返回被删除的节点
+func jsonPointerRemove(root *JsonNode, ptr string) (*JsonNode, error) {
+	if ptr == "" {
+		return nil, errors.New("cannot remove the root")
+	}
+	parent, token, err := jsonPointerParent(root, ptr)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case parent.IsObject():
+		val, ok := parent.object[token]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrJsonPathNotFound, ptr)
+		}
+		parent.Delete(token)
+		return val, nil
+	case parent.IsArray():
+		idx, err := jsonPointerIndex(parent, token, false)
+		if err != nil {
+			return nil, err
+		}
+		val := parent.array[idx]
+		parent.DeleteIndex(idx)
+		return val, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrJsonPathNotFound, ptr)
+}
+
+// JsonMergePatch 对节点副本执行 JSON Merge Patch（RFC 7396）并返回结果：
+// patch 为对象时逐字段合并，字段值为 null 时删除该字段，否则以 patch 替换
+func JsonMergePatch(doc, patch *JsonNode) *JsonNode {
+	if !patch.IsObject() {
+		return patch.Clone()
+	}
+	target := JsonNewObject()
+	if doc.IsObject() {
+		target = doc.Clone()
+	}
+	for _, key := range patch.Keys() {
+		val := patch.object[key]
+		if val == nil || val.rawString == nil {
+			target.Delete(key)
+			continue
+		}
+		_ = target.Set(key, JsonMergePatch(target.object[key], val))
+	}
+	return target
+}
+
+// JsonMergePatchBytes 解析 JSON Merge Patch 文档并对节点副本执行
+func JsonMergePatchBytes(doc *JsonNode, patch []byte) (*JsonNode, error) {
+	var p JsonNode
+	if err := p.UnmarshalJSON(bytes.TrimSpace(patch)); err != nil {
+		return nil, err
+	}
+	return JsonMergePatch(doc, &p), nil
+}
+
+// JsonMerge 深度合并两个节点并返回新节点：对象逐字段递归合并，其余类型以 src 覆盖 dst（包括 null），
+// 两者均为数组时按 strategy 合并
+func JsonMerge(dst, src *JsonNode, strategy JsonArrayStrategy) *JsonNode {
+	switch {
+	case dst.IsObject() && src.IsObject():
+		out := dst.Clone()
+		for _, key := range src.Keys() {
+			_ = out.Set(key, JsonMerge(out.object[key], src.object[key], strategy))
+		}
+		return out
+	case dst.IsArray() && src.IsArray():
+		out := dst.Clone()
+		switch strategy {
+		case JsonArrayAppend:
+			for _, item := range src.array {
+				_ = out.Append(item.Clone())
+			}
+		case JsonArrayUnion:
+			for _, item := range src.array {
+				exists := false
+				for _, cur := range out.array {
+					if jsonEqual(cur, item) {
+						exists = true
+						break
+					}
+				}
+				if !exists {
+					_ = out.Append(item.Clone())
+				}
+			}
+		case JsonArrayByIndex:
+			for i, item := range src.array {
+				if i < len(out.array) {
+					_ = out.SetIndex(i, JsonMerge(out.array[i], item, strategy))
+				} else {
+					_ = out.Append(item.Clone())
+				}
+			}
+		default:
+			return src.Clone()
+		}
+		return out
+	}
+	return src.Clone()
+}
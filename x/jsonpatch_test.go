@@ -0,0 +1,67 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestJsonPatchValueNull(t *testing.T) {
+	doc, err := JsonFromStringE(`{"a":1,"b":[1,2]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := JsonPatchBytes(doc, []byte(`[
+		{"op":"test","path":"/a","value":1},
+		{"op":"replace","path":"/a","value":null},
+		{"op":"test","path":"/a","value":null},
+		{"op":"add","path":"/b/-","value":null}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out.Marshal()); got != `{"a":null,"b":[1,2,null]}` {
+		t.Fatalf("JsonPatchBytes = %s", got)
+	}
+}
+
+// RFC 6902 4.1/4.3/4.6：add、replace、test 缺少 value 字段时必须报错，不能视为 null
+func TestJsonPatchMissingValue(t *testing.T) {
+	doc, err := JsonFromStringE(`{"a":null}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, patch := range []string{
+		`[{"op":"add","path":"/b"}]`,
+		`[{"op":"replace","path":"/a"}]`,
+		`[{"op":"test","path":"/a"}]`,
+	} {
+		if _, err = JsonPatchBytes(doc, []byte(patch)); !errors.Is(err, ErrJsonPatch) {
+			t.Errorf("JsonPatchBytes(%s) err = %v", patch, err)
+		}
+	}
+}
+
+func TestJsonDiffRoundTrip(t *testing.T) {
+	a, _ := JsonFromStringE(`{"a":1,"b":{"c":[1,2,3]},"d":"x"}`)
+	b, _ := JsonFromStringE(`{"a":null,"b":{"c":[1,4]},"e":null}`)
+	ops := JsonDiff(a, b)
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := JsonPatchBytes(a, data)
+	if err != nil {
+		t.Fatalf("JsonPatchBytes(%s): %v", data, err)
+	}
+	if !jsonEqual(out, b) {
+		t.Fatalf("patched = %s, want %s", out.Marshal(), b.Marshal())
+	}
+}
@@ -0,0 +1,770 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrJsonPathNotFound = errors.New("x: json path not found")    // 路径不存在
+	ErrJsonPathSyntax   = errors.New("x: json path syntax error") // 路径语法错误
+)
+
+// Get 按路径获取节点，例：data.items[0].price、$.data["a.b"]，路径不存在时返回空节点（IsEmpty 为 true）
+func (n *JsonNode) Get(path string) *JsonNode {
+	if node, err := n.GetE(path); err == nil {
+		return node
+	}
+	return &JsonNode{jType: jsonNull}
+}
+
+// GetE 按路径获取节点，路径不存在时返回 ErrJsonPathNotFound，语法错误时返回 ErrJsonPathSyntax
+func (n *JsonNode) GetE(path string) (*JsonNode, error) {
+	sels, err := jsonPathSingular(path)
+	if err != nil {
+		return nil, err
+	}
+	node := n
+	for _, sel := range sels {
+		if node = node.child(sel); node == nil {
+			return nil, fmt.Errorf("%w: %s", ErrJsonPathNotFound, path)
+		}
+	}
+	return node, nil
+}
+
+// Exists 路径是否存在
+func (n *JsonNode) Exists(path string) bool {
+	_, err := n.GetE(path)
+	return err == nil
+}
+
+// Query 执行 JSONPath 查询，支持 $、.name、['name']、[0]、[-1]、[0,1]、[start:end:step]、*、..（递归下降）
+// 及过滤表达式 [?(@.price < 10 && @.tag == 'a')]，过滤表达式支持 ==、!=、<、<=、>、>=、=~（正则）、&&、||、!。
+// 未匹配时返回空列表
+func (n *JsonNode) Query(expr string) ([]*JsonNode, error) {
+	segs, err := jsonPathParse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return jsonPathEval(n, n, segs), nil
+}
+
+// QueryOne 执行 JSONPath 查询并返回第一个结果，未匹配时返回 ErrJsonPathNotFound
+func (n *JsonNode) QueryOne(expr string) (*JsonNode, error) {
+	nodes, err := n.Query(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrJsonPathNotFound, expr)
+	}
+	return nodes[0], nil
+}
+
+// jsonPathSingular 解析点分路径，路径只能包含字段名称及数组索引
+func jsonPathSingular(path string) ([]jsonPathSelector, error) {
+	segs, err := jsonPathParse(jsonPathNormalize(path))
+	if err != nil {
+		return nil, err
+	}
+	sels := make([]jsonPathSelector, len(segs))
+	for i, seg := range segs {
+		if seg.descendant || len(seg.selectors) != 1 ||
+			(seg.selectors[0].kind != jsonSelName && seg.selectors[0].kind != jsonSelIndex) {
+			return nil, fmt.Errorf("%w: %q is not a singular path", ErrJsonPathSyntax, path)
+		}
+		sels[i] = seg.selectors[0]
+	}
+	return sels, nil
+}
+
+// child 按字段名称或数组索引（支持负数）获取子节点
+func (n *JsonNode) child(sel jsonPathSelector) *JsonNode {
+	if sel.kind == jsonSelName {
+		return n.Name(sel.name)
+	}
+	idx := sel.index
+	if idx < 0 {
+		idx += n.Size()
+	}
+	return n.Index(idx)
+}
+
+// jsonPathNormalize 将点分路径转换为 JSONPath 表达式
+func jsonPathNormalize(path string) string {
+	path = strings.TrimSpace(path)
+	switch {
+	case path == "" || path == "$":
+		return "$"
+	case strings.HasPrefix(path, "$"):
+		return path
+	case strings.HasPrefix(path, "["):
+		return "$" + path
+	}
+	return "$." + path
+}
+
+type jsonSelKind int8
+
+const (
+	jsonSelName     jsonSelKind = iota // 字段名称
+	jsonSelIndex                       // 数组索引
+	jsonSelWildcard                    // 通配符
+	jsonSelSlice                       // 数组切片
+	jsonSelFilter                      // 过滤表达式
+)
+
+// jsonPathSelector JSONPath 选择器
+type jsonPathSelector struct {
+	kind   jsonSelKind
+	name   string
+	index  int
+	slice  [3]*int
+	filter jsonFilterExpr
+}
+
+// jsonPathSegment JSONPath 路径段
+type jsonPathSegment struct {
+	descendant bool
+	selectors  []jsonPathSelector
+}
+
+// jsonPathParser JSONPath 解析器
+type jsonPathParser struct {
+	src string
+	pos int
+}
+
+// jsonPathParse 解析 JSONPath 表达式，必须以 $ 开头
+func jsonPathParse(expr string) ([]jsonPathSegment, error) {
+	p := &jsonPathParser{src: strings.TrimSpace(expr)}
+	if !p.consume("$") {
+		return nil, p.errorf("path must start with $")
+	}
+	segs, err := p.segments()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected character %q", p.src[p.pos])
+	}
+	return segs, nil
+}
+
+func (p *jsonPathParser) errorf(format string, v ...any) error {
+	return fmt.Errorf("%w: %s at position %d in %q", ErrJsonPathSyntax, fmt.Sprintf(format, v...), p.pos, p.src)
+}
+
+func (p *jsonPathParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *jsonPathParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *jsonPathParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// segments 解析路径段，遇到无法识别的字符时停止
+func (p *jsonPathParser) segments() ([]jsonPathSegment, error) {
+	var segs []jsonPathSegment
+	for p.pos < len(p.src) {
+		var seg jsonPathSegment
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek() == '[' {
+				sels, err := p.bracket()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = sels
+			} else if sel, err := p.dotMember(); err != nil {
+				return nil, err
+			} else {
+				seg.selectors = []jsonPathSelector{sel}
+			}
+		case p.consume("."):
+			sel, err := p.dotMember()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []jsonPathSelector{sel}
+		case p.peek() == '[':
+			sels, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = sels
+		default:
+			return segs, nil
+		}
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+// dotMember 解析 .name 或 .*
+func (p *jsonPathParser) dotMember() (jsonPathSelector, error) {
+	if p.consume("*") {
+		return jsonPathSelector{kind: jsonSelWildcard}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '.' || c == '[' || c == ' ' || c == ')' || c == '=' || c == '!' || c == '<' || c == '>' ||
+			c == '&' || c == '|' || c == ',' || c == ']' {
+			break
+		}
+		p.pos++
+	}
+	if start == p.pos {
+		return jsonPathSelector{}, p.errorf("expected member name")
+	}
+	return jsonPathSelector{kind: jsonSelName, name: p.src[start:p.pos]}, nil
+}
+
+// bracket 解析 [...] 选择器列表
+func (p *jsonPathParser) bracket() ([]jsonPathSelector, error) {
+	p.consume("[")
+	var sels []jsonPathSelector
+	for {
+		p.skipSpace()
+		sel, err := p.bracketSelector()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return sels, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+// bracketSelector 解析单个方括号选择器
+func (p *jsonPathParser) bracketSelector() (jsonPathSelector, error) {
+	switch c := p.peek(); {
+	case c == '*':
+		p.pos++
+		return jsonPathSelector{kind: jsonSelWildcard}, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return jsonPathSelector{}, err
+		}
+		return jsonPathSelector{kind: jsonSelName, name: s}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		expr, err := p.filterOr()
+		if err != nil {
+			return jsonPathSelector{}, err
+		}
+		return jsonPathSelector{kind: jsonSelFilter, filter: expr}, nil
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		var parts [3]*int
+		idx := 0
+		for {
+			p.skipSpace()
+			if n, ok := p.integer(); ok {
+				parts[idx] = &n
+			}
+			p.skipSpace()
+			if idx < 2 && p.consume(":") {
+				idx++
+				continue
+			}
+			break
+		}
+		if idx == 0 {
+			if parts[0] == nil {
+				return jsonPathSelector{}, p.errorf("expected index")
+			}
+			return jsonPathSelector{kind: jsonSelIndex, index: *parts[0]}, nil
+		}
+		return jsonPathSelector{kind: jsonSelSlice, slice: parts}, nil
+	}
+	return jsonPathSelector{}, p.errorf("invalid selector")
+}
+
+// integer 解析整数
+func (p *jsonPathParser) integer() (int, bool) {
+	start := p.pos
+	if p.peek() == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, false
+	}
+	return n, true
+}
+
+// quoted 解析单引号或双引号字符串
+func (p *jsonPathParser) quoted() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(e)
+			}
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// jsonFilterExpr 过滤表达式，current 为 @ 节点，root 为 $ 节点
+type jsonFilterExpr func(root, current *JsonNode) bool
+
+// jsonFilterOperand 过滤表达式操作数，返回值及是否存在
+type jsonFilterOperand func(root, current *JsonNode) (any, bool)
+
+// filterOr 解析 || 表达式
+func (p *jsonPathParser) filterOr() (jsonFilterExpr, error) {
+	left, err := p.filterAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("||") {
+			return left, nil
+		}
+		p.skipSpace()
+		right, err := p.filterAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(root, current *JsonNode) bool { return l(root, current) || right(root, current) }
+	}
+}
+
+// filterAnd 解析 && 表达式
+func (p *jsonPathParser) filterAnd() (jsonFilterExpr, error) {
+	left, err := p.filterUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		p.skipSpace()
+		right, err := p.filterUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(root, current *JsonNode) bool { return l(root, current) && right(root, current) }
+	}
+}
+
+// filterUnary 解析 !、括号及比较表达式
+func (p *jsonPathParser) filterUnary() (jsonFilterExpr, error) {
+	p.skipSpace()
+	if p.peek() == '!' && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		inner, err := p.filterUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(root, current *JsonNode) bool { return !inner(root, current) }, nil
+	}
+	if p.consume("(") {
+		inner, err := p.filterOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return inner, nil
+	}
+	left, isPath, err := p.filterOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	var op string
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "=~", "<", ">"} {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		if !isPath {
+			return nil, p.errorf("expected comparison operator")
+		}
+		return func(root, current *JsonNode) bool {
+			_, ok := left(root, current)
+			return ok
+		}, nil
+	}
+	p.skipSpace()
+	if op == "=~" {
+		if c := p.peek(); c != '\'' && c != '"' {
+			return nil, p.errorf("expected regular expression string")
+		}
+		pattern, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+		return func(root, current *JsonNode) bool {
+			v, ok := left(root, current)
+			s, isStr := v.(string)
+			return ok && isStr && re.MatchString(s)
+		}, nil
+	}
+	right, _, err := p.filterOperand()
+	if err != nil {
+		return nil, err
+	}
+	return func(root, current *JsonNode) bool {
+		lv, lok := left(root, current)
+		rv, rok := right(root, current)
+		return jsonFilterCompare(op, lv, lok, rv, rok)
+	}, nil
+}
+
+// filterOperand 解析路径或字面量操作数
+func (p *jsonPathParser) filterOperand() (jsonFilterOperand, bool, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		segs, err := p.segments()
+		if err != nil {
+			return nil, false, err
+		}
+		relative := c == '@'
+		return func(root, current *JsonNode) (any, bool) {
+			base := root
+			if relative {
+				base = current
+			}
+			nodes := jsonPathEval(root, base, segs)
+			if len(nodes) != 1 {
+				return nil, false
+			}
+			return jsonNodeScalar(nodes[0]), true
+		}, true, nil
+	case c == '\'' || c == '"':
+		s, err := p.quoted()
+		if err != nil {
+			return nil, false, err
+		}
+		return func(_, _ *JsonNode) (any, bool) { return s, true }, false, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		num := json.Number(p.src[start:p.pos])
+		if !json.Valid([]byte(num)) {
+			return nil, false, p.errorf("invalid number")
+		}
+		return func(_, _ *JsonNode) (any, bool) { return num, true }, false, nil
+	}
+	for lit, val := range map[string]any{"true": true, "false": false, "null": nil} {
+		if p.consume(lit) {
+			v := val
+			return func(_, _ *JsonNode) (any, bool) { return v, true }, false, nil
+		}
+	}
+	return nil, false, p.errorf("invalid filter operand")
+}
+
+// jsonFilterCompare 比较过滤表达式操作数
+func jsonFilterCompare(op string, lv any, lok bool, rv any, rok bool) bool {
+	if !lok || !rok {
+		switch op {
+		case "==", "<=", ">=":
+			return !lok && !rok
+		case "!=":
+			return lok != rok
+		}
+		return false
+	}
+	switch op {
+	case "==":
+		return jsonScalarEqual(lv, rv)
+	case "!=":
+		return !jsonScalarEqual(lv, rv)
+	}
+	if ln, ok := lv.(json.Number); ok {
+		if rn, ok := rv.(json.Number); ok {
+			c, ok := jsonNumberCompare(ln, rn)
+			if !ok {
+				return false
+			}
+			switch op {
+			case "<":
+				return c < 0
+			case "<=":
+				return c <= 0
+			case ">":
+				return c > 0
+			case ">=":
+				return c >= 0
+			}
+		}
+	}
+	if ls, ok := lv.(string); ok {
+		if rs, ok := rv.(string); ok {
+			switch op {
+			case "<":
+				return ls < rs
+			case "<=":
+				return ls <= rs
+			case ">":
+				return ls > rs
+			case ">=":
+				return ls >= rs
+			}
+		}
+	}
+	return false
+}
+
+// jsonScalarEqual 比较两个标量值，对象及数组按 JSON 文本比较
+func jsonScalarEqual(a, b any) bool {
+	if an, ok := a.(*JsonNode); ok {
+		if bn, ok := b.(*JsonNode); ok {
+			return an.String() == bn.String()
+		}
+		return false
+	}
+	if an, ok := a.(json.Number); ok {
+		if bn, ok := b.(json.Number); ok {
+			c, ok := jsonNumberCompare(an, bn)
+			return (ok && c == 0) || an == bn
+		}
+		return false
+	}
+	return a == b
+}
+
+// jsonNumberCompare 无损比较两个数值：均为整数时按 int64 比较，否则按 big.Rat 比较，
+// 指数超出 ±1000 的数值不参与比较，返回 false
+func jsonNumberCompare(a, b json.Number) (int, bool) {
+	if ai, err := a.Int64(); err == nil {
+		if bi, err := b.Int64(); err == nil {
+			return cmp.Compare(ai, bi), true
+		}
+	}
+	ar, ok := jsonNumberRat(a)
+	if !ok {
+		return 0, false
+	}
+	br, ok := jsonNumberRat(b)
+	if !ok {
+		return 0, false
+	}
+	return ar.Cmp(br), true
+}
+
+// jsonNumberRat 将数值转为 big.Rat，限制指数范围避免构造的数值耗尽内存
+func jsonNumberRat(n json.Number) (*big.Rat, bool) {
+	s := string(n)
+	if idx := strings.IndexAny(s, "eE"); idx >= 0 {
+		exp, err := strconv.Atoi(s[idx+1:])
+		if err != nil || exp > 1000 || exp < -1000 {
+			return nil, false
+		}
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// jsonNodeScalar 获取节点的标量值：数值为 json.Number 以无损比较，对象及数组返回节点本身
+func jsonNodeScalar(n *JsonNode) any {
+	if n.IsObject() || n.IsArray() {
+		return n
+	}
+	switch n.jType {
+	case jsonNumber:
+		if num, ok := n.value.(json.Number); ok {
+			return num
+		}
+		return json.Number(n.text())
+	case jsonString, jsonBoolean:
+		return n.value
+	}
+	return nil
+}
+
+// jsonPathEval 执行路径段选择
+func jsonPathEval(root, node *JsonNode, segs []jsonPathSegment) []*JsonNode {
+	nodes := []*JsonNode{node}
+	for _, seg := range segs {
+		var next []*JsonNode
+		for _, n := range nodes {
+			targets := []*JsonNode{n}
+			if seg.descendant {
+				targets = jsonDescendants(n, nil)
+			}
+			for _, t := range targets {
+				for _, sel := range seg.selectors {
+					next = append(next, jsonPathSelect(root, t, sel)...)
+				}
+			}
+		}
+		nodes = next
+	}
+	if nodes == nil {
+		nodes = []*JsonNode{}
+	}
+	return nodes
+}
+
+// jsonDescendants 获取节点及其全部后代节点（先序）
+func jsonDescendants(n *JsonNode, out []*JsonNode) []*JsonNode {
+	out = append(out, n)
+	for _, child := range jsonChildren(n) {
+		out = jsonDescendants(child, out)
+	}
+	return out
+}
+
+// jsonChildren 获取子节点，对象按字段名称排序
+func jsonChildren(n *JsonNode) []*JsonNode {
+	if n.IsArray() {
+		return n.array
+	}
+	if n.IsObject() {
+		keys := n.Keys()
+		sort.Strings(keys)
+		children := make([]*JsonNode, len(keys))
+		for i, key := range keys {
+			children[i] = n.object[key]
+		}
+		return children
+	}
+	return nil
+}
+
+// jsonPathSelect 对单个节点执行选择器
+func jsonPathSelect(root, n *JsonNode, sel jsonPathSelector) []*JsonNode {
+	switch sel.kind {
+	case jsonSelName, jsonSelIndex:
+		if child := n.child(sel); child != nil {
+			return []*JsonNode{child}
+		}
+	case jsonSelWildcard:
+		return jsonChildren(n)
+	case jsonSelSlice:
+		if !n.IsArray() {
+			return nil
+		}
+		return jsonSlice(n.array, sel.slice)
+	case jsonSelFilter:
+		var out []*JsonNode
+		for _, child := range jsonChildren(n) {
+			if sel.filter(root, child) {
+				out = append(out, child)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// jsonSlice 执行数组切片 [start:end:step]
+func jsonSlice(array []*JsonNode, parts [3]*int) []*JsonNode {
+	size := len(array)
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	if step == 0 {
+		return nil
+	}
+	norm := func(i int) int {
+		if i < 0 {
+			return i + size
+		}
+		return i
+	}
+	var out []*JsonNode
+	if step > 0 {
+		start, end := 0, size
+		if parts[0] != nil {
+			start = min(max(norm(*parts[0]), 0), size)
+		}
+		if parts[1] != nil {
+			end = min(max(norm(*parts[1]), 0), size)
+		}
+		for i := start; i < end; i += step {
+			out = append(out, array[i])
+		}
+	} else {
+		start, end := size-1, -1
+		if parts[0] != nil {
+			start = min(max(norm(*parts[0]), -1), size-1)
+		}
+		if parts[1] != nil {
+			end = min(max(norm(*parts[1]), -1), size-1)
+		}
+		for i := start; i > end; i += step {
+			out = append(out, array[i])
+		}
+	}
+	return out
+}
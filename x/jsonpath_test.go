@@ -0,0 +1,57 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJsonPathFilterNumbers(t *testing.T) {
+	node, err := JsonFromStringE(`{"items":[
+		{"id":9007199254740992,"price":0.1},
+		{"id":9007199254740993,"price":0.30000000000000004},
+		{"id":1e400,"price":3},
+		{"id":-5,"price":"3"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$.items[?(@.id==9007199254740993)].id", "9007199254740993"},
+		{"$.items[?(@.id==9007199254740992)].id", "9007199254740992"},
+		{"$.items[?(@.id>9007199254740992)].id", "9007199254740993,1e400"},
+		{"$.items[?(@.id<0)].id", "-5"},
+		{"$.items[?(@.id==1e400)].id", "1e400"},
+		{"$.items[?(@.price==0.1)].id", "9007199254740992"},
+		{"$.items[?(@.price==1e-1)].id", "9007199254740992"},
+		{"$.items[?(@.price>0.3)].id", "9007199254740993,1e400"},
+		{"$.items[?(@.price==3.0)].id", "1e400"},
+		{"$.items[?(@.price=='3')].id", "-5"},
+		{"$.items[?(@.id>1e5000)].id", ""},
+	}
+	for _, tt := range tests {
+		nodes, err := node.Query(tt.expr)
+		if err != nil {
+			t.Fatalf("Query(%s): %v", tt.expr, err)
+		}
+		got := make([]string, len(nodes))
+		for i, n := range nodes {
+			got[i] = n.String()
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("Query(%s) = %v, want %s", tt.expr, got, tt.want)
+		}
+	}
+	if _, err = node.Query("$.items[?(@.id==1.2.3)]"); err == nil {
+		t.Error("invalid number literal accepted")
+	}
+}
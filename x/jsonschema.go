@@ -0,0 +1,637 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// JsonSchemaViolation JSON Schema 校验失败项
+type JsonSchemaViolation struct {
+	Pointer string // 失败值的 JSON Pointer（RFC 6901），根节点为空字符串，例：/items/0/price
+	Keyword string // 失败的关键字，例：required
+	Message string // 失败原因
+}
+
+func (v JsonSchemaViolation) Error() string {
+	if v.Pointer == "" {
+		return "(root): " + v.Message
+	}
+	return fmt.Sprintf("%s: %s", v.Pointer, v.Message)
+}
+
+// JsonSchema 已编译的 JSON Schema，支持 draft 2020-12 常用关键字：
+// type、enum、const、required、properties、additionalProperties、minProperties、maxProperties、
+// items、prefixItems、minItems、maxItems、uniqueItems、minLength、maxLength、pattern、
+// minimum、maximum、exclusiveMinimum、exclusiveMaximum、multipleOf、allOf、anyOf、oneOf、not、
+// $ref（仅支持文档内引用，例：#/$defs/item）及 format。
+// format 支持 date-time、date、time、email、uri、uuid、ipv4、ipv6、china-mobile、china-id，未知格式忽略。
+// 仅由 $ref 组成的循环引用在编译时返回错误，经 allOf 等关键字形成且不消耗节点的循环引用在校验时报告为 $ref 失败项
+type JsonSchema struct {
+	root *jsonSchemaNode
+}
+
+// jsonSchemaNode 已编译的子 Schema
+type jsonSchemaNode struct {
+	always     *bool // 布尔 Schema，true 总是通过，false 总是失败
+	types      []string
+	enum       []*JsonNode
+	constVal   *JsonNode
+	hasConst   bool
+	required   []string
+	properties map[string]*jsonSchemaNode
+	additional *jsonSchemaNode
+	minProps   *int
+	maxProps   *int
+	items      *jsonSchemaNode
+	prefix     []*jsonSchemaNode
+	minItems   *int
+	maxItems   *int
+	unique     bool
+	minLength  *int
+	maxLength  *int
+	pattern    *regexp.Regexp
+	minimum    *big.Rat
+	maximum    *big.Rat
+	exclMin    *big.Rat
+	exclMax    *big.Rat
+	multipleOf *big.Rat
+	format     string
+	allOf      []*jsonSchemaNode
+	anyOf      []*jsonSchemaNode
+	oneOf      []*jsonSchemaNode
+	not        *jsonSchemaNode
+	ref        string
+	refNode    *jsonSchemaNode
+}
+
+// NewJsonSchema 编译 JSON Schema，Schema 非法时返回错误
+func NewJsonSchema(schema string) (*JsonSchema, error) {
+	var val any
+	if err := jsonUnmarshal([]byte(schema), &val); err != nil {
+		return nil, err
+	}
+	node, err := new(JsonNode).convert(val)
+	if err != nil {
+		return nil, err
+	}
+	c := &jsonSchemaCompiler{nodes: make(map[string]*jsonSchemaNode)}
+	root, err := c.compile(node, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range c.refs {
+		target, ok := c.nodes[strings.TrimPrefix(s.ref, "#")]
+		if !ok {
+			return nil, fmt.Errorf("[x.JsonSchema] unresolved $ref %q", s.ref)
+		}
+		s.refNode = target
+	}
+	// 仅由 $ref 组成的引用链不会消耗任何节点，成环时校验无法结束
+	for _, s := range c.refs {
+		seen := map[*jsonSchemaNode]bool{s: true}
+		for next := s.refNode; next != nil; next = next.refNode {
+			if seen[next] {
+				return nil, fmt.Errorf("[x.JsonSchema] circular $ref %q", s.ref)
+			}
+			seen[next] = true
+		}
+	}
+	return &JsonSchema{root: root}, nil
+}
+
+// Validate 校验 JSON 节点，返回全部失败项，校验通过时返回空列表
+func (s *JsonSchema) Validate(node *JsonNode) []JsonSchemaViolation {
+	var out []JsonSchemaViolation
+	run := &jsonSchemaRun{active: make(map[jsonSchemaVisit]bool)}
+	s.root.validate(node, "", &out, run)
+	// not、anyOf 等内部的失败项不会直接输出，循环引用须单独报告
+	if run.cycle != nil && !slices.Contains(out, *run.cycle) {
+		out = append(out, *run.cycle)
+	}
+	return out
+}
+
+// ValidateBytes 校验 JSON 数据，返回全部失败项，数据不是合法 JSON 时返回错误
+func (s *JsonSchema) ValidateBytes(data []byte) ([]JsonSchemaViolation, error) {
+	var val any
+	if err := jsonUnmarshal(data, &val); err != nil {
+		return nil, err
+	}
+	node, err := new(JsonNode).convert(val)
+	if err != nil {
+		return nil, err
+	}
+	return s.Validate(node), nil
+}
+
+// jsonSchemaCompiler Schema 编译器，记录各子 Schema 的 JSON Pointer 以解析 $ref
+type jsonSchemaCompiler struct {
+	nodes map[string]*jsonSchemaNode
+	refs  []*jsonSchemaNode
+}
+
+// compile 编译子 Schema
+func (c *jsonSchemaCompiler) compile(node *JsonNode, ptr string) (*jsonSchemaNode, error) {
+	s := &jsonSchemaNode{}
+	c.nodes[ptr] = s
+	if node.jType == jsonBoolean {
+		b := node.value.(bool)
+		s.always = &b
+		return s, nil
+	}
+	if !node.IsObject() {
+		return nil, fmt.Errorf("[x.JsonSchema] %s: schema must be an object or boolean", jsonPointerOrRoot(ptr))
+	}
+	fail := func(keyword, msg string) error {
+		return fmt.Errorf("[x.JsonSchema] %s: %s %s", jsonPointerOrRoot(ptr+"/"+keyword), keyword, msg)
+	}
+	sub := func(keyword string) (*jsonSchemaNode, error) {
+		n := node.Name(keyword)
+		if n == nil {
+			return nil, nil
+		}
+		return c.compile(n, ptr+"/"+keyword)
+	}
+	subList := func(keyword string) ([]*jsonSchemaNode, error) {
+		n := node.Name(keyword)
+		if n == nil {
+			return nil, nil
+		}
+		if !n.IsArray() {
+			return nil, fail(keyword, "must be an array")
+		}
+		list := make([]*jsonSchemaNode, len(n.array))
+		for i, item := range n.array {
+			child, err := c.compile(item, ptr+"/"+keyword+"/"+strconv.Itoa(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = child
+		}
+		return list, nil
+	}
+	count := func(keyword string) (*int, error) {
+		n := node.Name(keyword)
+		if n == nil {
+			return nil, nil
+		}
+		v, err := n.Int64E()
+		if err != nil || n.jType != jsonNumber || v < 0 {
+			return nil, fail(keyword, "must be a non-negative integer")
+		}
+		i := int(v)
+		return &i, nil
+	}
+	number := func(keyword string) (*big.Rat, error) {
+		n := node.Name(keyword)
+		if n == nil {
+			return nil, nil
+		}
+		if n.jType != jsonNumber {
+			return nil, fail(keyword, "must be a number")
+		}
+		r, _ := new(big.Rat).SetString(*n.rawString)
+		return r, nil
+	}
+
+	var err error
+	if t := node.Name("type"); t != nil {
+		switch {
+		case t.jType == jsonString:
+			s.types = []string{t.value.(string)}
+		case t.IsArray():
+			for _, item := range t.array {
+				if item.jType != jsonString {
+					return nil, fail("type", "must be a string or an array of strings")
+				}
+				s.types = append(s.types, item.value.(string))
+			}
+		default:
+			return nil, fail("type", "must be a string or an array of strings")
+		}
+	}
+	if e := node.Name("enum"); e != nil {
+		if !e.IsArray() {
+			return nil, fail("enum", "must be an array")
+		}
+		s.enum = e.array
+	}
+	if cv, ok := node.object["const"]; ok {
+		s.constVal, s.hasConst = cv, true
+	}
+	if r := node.Name("required"); r != nil {
+		if !r.IsArray() {
+			return nil, fail("required", "must be an array of strings")
+		}
+		for _, item := range r.array {
+			if item.jType != jsonString {
+				return nil, fail("required", "must be an array of strings")
+			}
+			s.required = append(s.required, item.value.(string))
+		}
+	}
+	if p := node.Name("properties"); p != nil {
+		if !p.IsObject() {
+			return nil, fail("properties", "must be an object")
+		}
+		s.properties = make(map[string]*jsonSchemaNode, len(p.object))
+		for key, val := range p.object {
+			if s.properties[key], err = c.compile(val, ptr+"/properties/"+jsonPointerEscape(key)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if s.additional, err = sub("additionalProperties"); err != nil {
+		return nil, err
+	}
+	if s.items, err = sub("items"); err != nil {
+		return nil, err
+	}
+	if s.not, err = sub("not"); err != nil {
+		return nil, err
+	}
+	if s.prefix, err = subList("prefixItems"); err != nil {
+		return nil, err
+	}
+	if s.allOf, err = subList("allOf"); err != nil {
+		return nil, err
+	}
+	if s.anyOf, err = subList("anyOf"); err != nil {
+		return nil, err
+	}
+	if s.oneOf, err = subList("oneOf"); err != nil {
+		return nil, err
+	}
+	for _, defs := range []string{"$defs", "definitions"} {
+		if d := node.Name(defs); d != nil && d.IsObject() {
+			for key, val := range d.object {
+				if _, err = c.compile(val, ptr+"/"+defs+"/"+jsonPointerEscape(key)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for keyword, dst := range map[string]**int{"minProperties": &s.minProps, "maxProperties": &s.maxProps,
+		"minItems": &s.minItems, "maxItems": &s.maxItems, "minLength": &s.minLength, "maxLength": &s.maxLength} {
+		if *dst, err = count(keyword); err != nil {
+			return nil, err
+		}
+	}
+	for keyword, dst := range map[string]**big.Rat{"minimum": &s.minimum, "maximum": &s.maximum,
+		"exclusiveMinimum": &s.exclMin, "exclusiveMaximum": &s.exclMax, "multipleOf": &s.multipleOf} {
+		if *dst, err = number(keyword); err != nil {
+			return nil, err
+		}
+	}
+	if s.multipleOf != nil && s.multipleOf.Sign() <= 0 {
+		return nil, fail("multipleOf", "must be greater than 0")
+	}
+	if u := node.Name("uniqueItems"); u != nil {
+		s.unique = u.jType == jsonBoolean && u.value.(bool)
+	}
+	if p := node.Name("pattern"); p != nil {
+		if p.jType != jsonString {
+			return nil, fail("pattern", "must be a string")
+		}
+		if s.pattern, err = regexp.Compile(p.value.(string)); err != nil {
+			return nil, fail("pattern", err.Error())
+		}
+	}
+	if f := node.Name("format"); f != nil && f.jType == jsonString {
+		s.format = f.value.(string)
+	}
+	if r := node.Name("$ref"); r != nil {
+		if r.jType != jsonString || !strings.HasPrefix(r.value.(string), "#") {
+			return nil, fail("$ref", "must be a local reference starting with #")
+		}
+		s.ref = r.value.(string)
+		c.refs = append(c.refs, s)
+	}
+	return s, nil
+}
+
+// jsonSchemaVisit 正在校验的子 Schema 及节点
+type jsonSchemaVisit struct {
+	schema *jsonSchemaNode
+	node   *JsonNode
+}
+
+// jsonSchemaRun 单次校验状态，记录正在校验的子 Schema 及节点以检测循环引用
+type jsonSchemaRun struct {
+	active map[jsonSchemaVisit]bool
+	cycle  *JsonSchemaViolation // 首个循环引用失败项
+}
+
+// validate 校验节点，失败项追加到 out，
+// 同一子 Schema 在未消耗任何节点的情况下再次校验同一节点时视为循环引用
+func (s *jsonSchemaNode) validate(node *JsonNode, ptr string, out *[]JsonSchemaViolation, run *jsonSchemaRun) {
+	fail := func(keyword, format string, v ...any) {
+		*out = append(*out, JsonSchemaViolation{Pointer: ptr, Keyword: keyword, Message: fmt.Sprintf(format, v...)})
+	}
+	visit := jsonSchemaVisit{schema: s, node: node}
+	if run.active[visit] {
+		fail("$ref", "circular schema reference")
+		if run.cycle == nil {
+			v := (*out)[len(*out)-1]
+			run.cycle = &v
+		}
+		return
+	}
+	run.active[visit] = true
+	defer delete(run.active, visit)
+	if s.always != nil {
+		if !*s.always {
+			fail("false", "no value is allowed here")
+		}
+		return
+	}
+	if s.refNode != nil {
+		s.refNode.validate(node, ptr, out, run)
+	}
+
+	kind := jsonSchemaType(node)
+	if len(s.types) > 0 {
+		ok := false
+		for _, t := range s.types {
+			if t == kind || (t == "integer" && kind == "number" && jsonSchemaRat(node).IsInt()) ||
+				(t == "number" && kind == "integer") {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("type", "expected %s, got %s", strings.Join(s.types, " or "), kind)
+			return
+		}
+	}
+	if s.enum != nil {
+		ok := false
+		for _, e := range s.enum {
+			if jsonEqual(e, node) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("enum", "value must be one of %s", JsonToString(s.enum))
+		}
+	}
+	if s.hasConst && !jsonEqual(s.constVal, node) {
+		fail("const", "value must be %s", s.constVal.Marshal())
+	}
+
+	switch kind {
+	case "object":
+		for _, key := range s.required {
+			if _, ok := node.object[key]; !ok {
+				fail("required", "missing required property %q", key)
+			}
+		}
+		if s.minProps != nil && len(node.object) < *s.minProps {
+			fail("minProperties", "must have at least %d properties", *s.minProps)
+		}
+		if s.maxProps != nil && len(node.object) > *s.maxProps {
+			fail("maxProperties", "must have at most %d properties", *s.maxProps)
+		}
+		keys := node.Keys()
+		sort.Strings(keys)
+		for _, key := range keys {
+			child, p := node.object[key], ptr+"/"+jsonPointerEscape(key)
+			if prop, ok := s.properties[key]; ok {
+				prop.validate(child, p, out, run)
+			} else if s.additional != nil {
+				if s.additional.always != nil && !*s.additional.always {
+					*out = append(*out, JsonSchemaViolation{Pointer: p, Keyword: "additionalProperties",
+						Message: fmt.Sprintf("additional property %q is not allowed", key)})
+				} else {
+					s.additional.validate(child, p, out, run)
+				}
+			}
+		}
+	case "array":
+		size := len(node.array)
+		if s.minItems != nil && size < *s.minItems {
+			fail("minItems", "must have at least %d items", *s.minItems)
+		}
+		if s.maxItems != nil && size > *s.maxItems {
+			fail("maxItems", "must have at most %d items", *s.maxItems)
+		}
+		for i, child := range node.array {
+			p := ptr + "/" + strconv.Itoa(i)
+			if i < len(s.prefix) {
+				s.prefix[i].validate(child, p, out, run)
+			} else if s.items != nil {
+				s.items.validate(child, p, out, run)
+			}
+		}
+		if s.unique {
+			for i := 0; i < size; i++ {
+				for j := i + 1; j < size; j++ {
+					if jsonEqual(node.array[i], node.array[j]) {
+						fail("uniqueItems", "items at index %d and %d are equal", i, j)
+					}
+				}
+			}
+		}
+	case "string":
+		str := node.value.(string)
+		length := utf8.RuneCountInString(str)
+		if s.minLength != nil && length < *s.minLength {
+			fail("minLength", "length must be at least %d", *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			fail("maxLength", "length must be at most %d", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(str) {
+			fail("pattern", "does not match pattern %q", s.pattern.String())
+		}
+		if s.format != "" && !jsonSchemaFormat(s.format, str) {
+			fail("format", "is not a valid %s", s.format)
+		}
+	case "number", "integer":
+		num := jsonSchemaRat(node)
+		if s.minimum != nil && num.Cmp(s.minimum) < 0 {
+			fail("minimum", "must be >= %s", jsonRatDecimal(s.minimum))
+		}
+		if s.maximum != nil && num.Cmp(s.maximum) > 0 {
+			fail("maximum", "must be <= %s", jsonRatDecimal(s.maximum))
+		}
+		if s.exclMin != nil && num.Cmp(s.exclMin) <= 0 {
+			fail("exclusiveMinimum", "must be > %s", jsonRatDecimal(s.exclMin))
+		}
+		if s.exclMax != nil && num.Cmp(s.exclMax) >= 0 {
+			fail("exclusiveMaximum", "must be < %s", jsonRatDecimal(s.exclMax))
+		}
+		if s.multipleOf != nil && !new(big.Rat).Quo(num, s.multipleOf).IsInt() {
+			fail("multipleOf", "must be a multiple of %s", jsonRatDecimal(s.multipleOf))
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(node, ptr, out, run)
+	}
+	if len(s.anyOf) > 0 {
+		ok := false
+		for _, sub := range s.anyOf {
+			if sub.valid(node, ptr, run) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("anyOf", "must match at least one schema in anyOf")
+		}
+	}
+	if len(s.oneOf) > 0 {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if sub.valid(node, ptr, run) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("oneOf", "must match exactly one schema in oneOf, matched %d", matched)
+		}
+	}
+	if s.not != nil && s.not.valid(node, ptr, run) {
+		fail("not", "must not match the schema in not")
+	}
+}
+
+// valid 节点是否通过校验
+func (s *jsonSchemaNode) valid(node *JsonNode, ptr string, run *jsonSchemaRun) bool {
+	var out []JsonSchemaViolation
+	s.validate(node, ptr, &out, run)
+	return len(out) == 0
+}
+
+// jsonSchemaType 获取节点的 JSON Schema 类型
+func jsonSchemaType(node *JsonNode) string {
+	switch {
+	case node == nil || node.rawString == nil:
+		return "null"
+	case node.IsObject():
+		return "object"
+	case node.IsArray():
+		return "array"
+	case node.jType == jsonString:
+		return "string"
+	case node.jType == jsonBoolean:
+		return "boolean"
+	}
+	return "number"
+}
+
+// jsonSchemaRat 获取数值节点的精确值
+func jsonSchemaRat(node *JsonNode) *big.Rat {
+	r, ok := new(big.Rat).SetString(*node.rawString)
+	if !ok {
+		return new(big.Rat)
+	}
+	return r
+}
+
+// jsonSchemaFormat 校验字符串格式，未知格式视为通过
+func jsonSchemaFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", value)
+		if err != nil {
+			_, err = time.Parse("15:04:05.999999999Z07:00", value)
+		}
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return regexpUUID.MatchString(value)
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	case "china-mobile":
+		return RegexpChinaMobile.MatchString(value)
+	case "china-id":
+		return RegexpChinaID.MatchString(value)
+	}
+	return true
+}
+
+var regexpUUID = regexp.MustCompile("^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$")
+
+// jsonEqual 比较两个节点的值是否相等，数值按精确值比较，对象忽略字段顺序
+func jsonEqual(a, b *JsonNode) bool {
+	ka, kb := jsonSchemaType(a), jsonSchemaType(b)
+	if ka != kb {
+		return false
+	}
+	switch ka {
+	case "null":
+		return true
+	case "object":
+		if len(a.object) != len(b.object) {
+			return false
+		}
+		for key, av := range a.object {
+			bv, ok := b.object[key]
+			if !ok || !jsonEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	case "array":
+		if len(a.array) != len(b.array) {
+			return false
+		}
+		for i := range a.array {
+			if !jsonEqual(a.array[i], b.array[i]) {
+				return false
+			}
+		}
+		return true
+	case "number":
+		return jsonSchemaRat(a).Cmp(jsonSchemaRat(b)) == 0
+	}
+	return a.value == b.value
+}
+
+// jsonPointerEscape 转义 JSON Pointer 路径片段：~ 转为 ~0，/ 转为 ~1
+func jsonPointerEscape(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// jsonPointerOrRoot 格式化 JSON Pointer，根节点显示为 #
+func jsonPointerOrRoot(ptr string) string {
+	if ptr == "" {
+		return "#"
+	}
+	return "#" + ptr
+}
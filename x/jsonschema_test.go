@@ -0,0 +1,65 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"testing"
+)
+
+func TestJsonSchemaRefCycleCompile(t *testing.T) {
+	tests := []string{
+		`{"$ref":"#"}`,
+		`{"$defs":{"a":{"$ref":"#/$defs/a"}},"$ref":"#/$defs/a"}`,
+		`{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#/$defs/a"}},"properties":{"x":{"$ref":"#/$defs/a"}}}`,
+	}
+	for _, schema := range tests {
+		if _, err := NewJsonSchema(schema); err == nil {
+			t.Errorf("NewJsonSchema(%s) accepted circular $ref", schema)
+		}
+	}
+}
+
+func TestJsonSchemaRefCycleValidate(t *testing.T) {
+	tests := []string{
+		`{"allOf":[{"$ref":"#"}]}`,
+		`{"anyOf":[{"$ref":"#"}]}`,
+		`{"not":{"$ref":"#"}}`,
+		`{"$defs":{"a":{"oneOf":[{"$ref":"#/$defs/b"}]},"b":{"allOf":[{"$ref":"#/$defs/a"}]}},"$ref":"#/$defs/a"}`,
+	}
+	for _, schema := range tests {
+		s, err := NewJsonSchema(schema)
+		if err != nil {
+			t.Fatalf("NewJsonSchema(%s): %v", schema, err)
+		}
+		if out, err := s.ValidateBytes([]byte(`{"a":1}`)); err != nil || len(out) == 0 {
+			t.Errorf("%s: violations = %v, %v", schema, out, err)
+		}
+	}
+}
+
+func TestJsonSchemaRecursiveRef(t *testing.T) {
+	s, err := NewJsonSchema(`{
+		"type":"object",
+		"required":["name"],
+		"properties":{
+			"name":{"type":"string"},
+			"children":{"type":"array","items":{"$ref":"#"}}
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := s.ValidateBytes([]byte(`{"name":"a","children":[{"name":"b","children":[{"name":"c"}]}]}`))
+	if err != nil || len(out) != 0 {
+		t.Fatalf("valid tree: %v, %v", out, err)
+	}
+	out, err = s.ValidateBytes([]byte(`{"name":"a","children":[{"name":"b","children":[{"name":1}]}]}`))
+	if err != nil || len(out) != 1 || out[0].Pointer != "/children/0/children/0/name" || out[0].Keyword != "type" {
+		t.Fatalf("invalid tree: %v, %v", out, err)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JsonScanner 流式读取 JSON 数组元素或 NDJSON 记录，每次只解析一个元素，内存占用与单个元素大小相关，与总数据量无关。
+// 用法与 bufio.Scanner 相同：
+//
+//	s := x.NewJsonArrayScanner(file, "data", "items")
+//	for s.Scan() {
+//		node := s.Node()
+//	}
+//	if err := s.Err(); err != nil {
+//	}
+type JsonScanner struct {
+	dec     *json.Decoder
+	keys    []string // 数组所在的对象字段路径
+	lines   bool     // 是否 NDJSON 模式
+	started bool
+	done    bool
+	index   int
+	node    *JsonNode
+	err     error
+}
+
+// NewJsonArrayScanner 创建 JSON 数组流式读取器，keys 为空时读取顶层数组，
+// 否则读取按 keys 逐级定位的对象字段中的数组，例：{"data":{"items":[...]}} 对应 keys 为 "data", "items"
+func NewJsonArrayScanner(r io.Reader, keys ...string) *JsonScanner {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &JsonScanner{dec: dec, keys: keys, index: -1}
+}
+
+// NewJsonLinesScanner 创建 NDJSON（JSON Lines）流式读取器，每条记录为一个 JSON 值，记录之间以换行或空白分隔
+func NewJsonLinesScanner(r io.Reader) *JsonScanner {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return &JsonScanner{dec: dec, lines: true, started: true, index: -1}
+}
+
+// Scan 读取下一个元素，没有更多元素或出错时返回 false，出错原因通过 Err 获取
+func (s *JsonScanner) Scan() bool {
+	s.node = nil
+	if s.done {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		if err := s.seek(); err != nil {
+			return s.fail(err)
+		}
+	}
+	if !s.lines && !s.dec.More() {
+		s.done = true
+		if _, err := s.dec.Token(); err != nil {
+			return s.fail(err)
+		}
+		return false
+	}
+	var val any
+	if err := s.dec.Decode(&val); err != nil {
+		if s.lines && err == io.EOF {
+			s.done = true
+			return false
+		}
+		return s.fail(err)
+	}
+	node, err := new(JsonNode).convert(val)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.index++
+	s.node = node
+	return true
+}
+
+// Node 当前元素
+func (s *JsonScanner) Node() *JsonNode {
+	return s.node
+}
+
+// Index 当前元素的序号，从 0 开始
+func (s *JsonScanner) Index() int {
+	return s.index
+}
+
+// Err 读取过程中的错误，正常结束时返回 nil
+func (s *JsonScanner) Err() error {
+	return s.err
+}
+
+// fail 记录错误并结束读取
+func (s *JsonScanner) fail(err error) bool {
+	s.done = true
+	if s.lines {
+		s.err = fmt.Errorf("[x.JsonScanner] record %d: %w", s.index+1, err)
+	} else {
+		s.err = fmt.Errorf("[x.JsonScanner] element %d: %w", s.index+1, err)
+	}
+	return false
+}
+
+// seek 定位到目标数组的起始位置
+func (s *JsonScanner) seek() error {
+	for _, key := range s.keys {
+		if err := s.expect(json.Delim('{')); err != nil {
+			return err
+		}
+		for {
+			if !s.dec.More() {
+				return fmt.Errorf("%w: key %q", ErrJsonPathNotFound, key)
+			}
+			tok, err := s.dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok == key {
+				break
+			}
+			if err = s.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.expect(json.Delim('['))
+}
+
+// expect 读取下一个分隔符并校验
+func (s *JsonScanner) expect(delim json.Delim) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("%w: expected %v, got %v", ErrJsonTypeMismatch, delim, tok)
+	}
+	return nil
+}
+
+// skip 跳过下一个值，逐个读取 Token 以避免加载整个值
+func (s *JsonScanner) skip() error {
+	depth := 0
+	for {
+		tok, err := s.dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	ErrPasswordHash      = errors.New("x: invalid password hash")          // 口令哈希格式错误或参数非法
+	ErrPasswordAlgorithm = errors.New("x: unsupported password algorithm") // 不支持的口令哈希算法
+)
+
+// 口令哈希参数上限，哈希来自存储数据，校验前须限制成本参数，避免构造的哈希耗尽 CPU 或内存
+const (
+	passwordMaxArgon2Memory = 1 << 20    // Argon2id 最大内存（KiB），1 GiB
+	passwordMaxArgon2Time   = 64         // Argon2id 最大迭代次数
+	passwordMaxBcryptCost   = 16         // bcrypt 最大成本
+	passwordMaxScryptLogN   = 20         // scrypt 最大 log2(N)
+	passwordMaxScryptR      = 32         // scrypt 最大块大小
+	passwordMaxScryptP      = 16         // scrypt 最大并行度
+	passwordMaxScryptMemory = 1 << 30    // scrypt 最大内存（128*N*r 字节），1 GiB
+	passwordMaxPBKDF2Iter   = 10_000_000 // PBKDF2 最大迭代次数
+	passwordMaxLength       = 1024       // 盐及哈希最大长度
+)
+
+// PasswordAlgorithm 口令哈希算法
+type PasswordAlgorithm string
+
+const (
+	PasswordArgon2id PasswordAlgorithm = "argon2id"      // Argon2id（RFC 9106），$argon2id$v=19$m=内存KiB,t=迭代次数,p=并行度$盐$哈希
+	PasswordBcrypt   PasswordAlgorithm = "bcrypt"        // bcrypt，$2a$成本$盐及哈希，口令最长 72 字节
+	PasswordScrypt   PasswordAlgorithm = "scrypt"        // scrypt，$scrypt$ln=log2(N),r=块大小,p=并行度$盐$哈希
+	PasswordPBKDF2   PasswordAlgorithm = "pbkdf2-sha256" // PBKDF2-HMAC-SHA256，$pbkdf2-sha256$i=迭代次数$盐$哈希
+)
+
+// PasswordHasher 口令哈希参数，零值字段使用默认值（参考 OWASP 口令存储建议），
+// 结果为自描述的 PHC 格式字符串，参数及盐均包含在结果中，校验时无需额外保存。
+// 成本参数有上限：Argon2id 内存 1 GiB、迭代 64 次，bcrypt 成本 16，scrypt N 2^20、r 32、p 16 且内存不超过 1 GiB，
+// PBKDF2 迭代 1000 万次，超出上限时 Hash 及 PasswordVerify 返回 ErrPasswordHash
+type PasswordHasher struct {
+	Algorithm        PasswordAlgorithm // 算法，默认 argon2id
+	Argon2Memory     uint32            // Argon2id 内存（KiB），默认 19456（19 MiB）
+	Argon2Time       uint32            // Argon2id 迭代次数，默认 2
+	Argon2Threads    uint8             // Argon2id 并行度，默认 1
+	BcryptCost       int               // bcrypt 成本，默认 12
+	ScryptN          int               // scrypt CPU/内存成本，须为 2 的幂，默认 32768
+	ScryptR          int               // scrypt 块大小，默认 8
+	ScryptP          int               // scrypt 并行度，默认 1
+	PBKDF2Iterations int               // PBKDF2 迭代次数，默认 600000
+	SaltLength       int               // 盐长度，默认 16，bcrypt 固定为 16
+	KeyLength        int               // 哈希长度，默认 32，bcrypt 固定为 23
+}
+
+// passwordParams 解析后的口令哈希
+type passwordParams struct {
+	hasher PasswordHasher
+	salt   []byte
+	key    []byte
+}
+
+// PasswordHash 使用默认参数（Argon2id）计算口令哈希，例：$argon2id$v=19$m=19456,t=2,p=1$...
+func PasswordHash(password string) (string, error) {
+	return PasswordHasher{}.Hash(password)
+}
+
+// PasswordVerify 以常量时间校验口令，支持本包生成的全部格式，哈希格式错误或成本参数超出上限时返回 ErrPasswordHash
+func PasswordVerify(password string, encoded string) (bool, error) {
+	if strings.HasPrefix(encoded, "$2") {
+		if cost, err := bcrypt.Cost([]byte(encoded)); err == nil && cost > passwordMaxBcryptCost {
+			return false, fmt.Errorf("%w: bcrypt cost %d exceeds %d", ErrPasswordHash, cost, passwordMaxBcryptCost)
+		}
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		// 超过 72 字节的口令无法生成 bcrypt 哈希，视为不匹配
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%w: %v", ErrPasswordHash, err)
+		}
+		return true, nil
+	}
+	params, err := passwordParse(encoded)
+	if err != nil {
+		return false, err
+	}
+	key, err := params.hasher.derive(password, params.salt)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(key, params.key) == 1, nil
+}
+
+// PasswordNeedsRehash 哈希是否与默认参数不一致，校验通过后应使用 PasswordHash 重新计算并保存
+func PasswordNeedsRehash(encoded string) bool {
+	return PasswordHasher{}.NeedsRehash(encoded)
+}
+
+// Hash 计算口令哈希，返回 PHC 格式字符串
+func (h PasswordHasher) Hash(password string) (string, error) {
+	h = h.normalize()
+	if err := h.check(); err != nil {
+		return "", err
+	}
+	if h.Algorithm == PasswordBcrypt {
+		encoded, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := h.derive(password, salt)
+	if err != nil {
+		return "", err
+	}
+	b64 := base64.RawStdEncoding
+	return "$" + string(h.Algorithm) + "$" + h.params() + "$" + b64.EncodeToString(salt) + "$" + b64.EncodeToString(key), nil
+}
+
+// NeedsRehash 哈希的算法或参数是否与当前参数不一致，例：提高成本参数或更换算法后，用户登录校验通过时重新计算哈希
+func (h PasswordHasher) NeedsRehash(encoded string) bool {
+	h = h.normalize()
+	if strings.HasPrefix(encoded, "$2") {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || h.Algorithm != PasswordBcrypt || cost != h.BcryptCost
+	}
+	params, err := passwordParse(encoded)
+	if err != nil || params.hasher.Algorithm != h.Algorithm {
+		return true
+	}
+	return params.hasher.params() != h.params() || len(params.salt) != h.SaltLength || len(params.key) != h.KeyLength
+}
+
+// normalize 填充默认参数
+func (h PasswordHasher) normalize() PasswordHasher {
+	if h.Algorithm == "" {
+		h.Algorithm = PasswordArgon2id
+	}
+	if h.Argon2Memory == 0 {
+		h.Argon2Memory = 19456
+	}
+	if h.Argon2Time == 0 {
+		h.Argon2Time = 2
+	}
+	if h.Argon2Threads == 0 {
+		h.Argon2Threads = 1
+	}
+	if h.BcryptCost == 0 {
+		h.BcryptCost = 12
+	}
+	if h.ScryptN == 0 {
+		h.ScryptN = 32768
+	}
+	if h.ScryptR == 0 {
+		h.ScryptR = 8
+	}
+	if h.ScryptP == 0 {
+		h.ScryptP = 1
+	}
+	if h.PBKDF2Iterations == 0 {
+		h.PBKDF2Iterations = 600000
+	}
+	if h.SaltLength == 0 {
+		h.SaltLength = 16
+	}
+	if h.KeyLength == 0 {
+		h.KeyLength = 32
+	}
+	return h
+}
+
+// check 校验参数范围，防止超出上限的成本参数
+func (h PasswordHasher) check() error {
+	limit := func(name string, value, maximum int64) error {
+		if value < 1 || value > maximum {
+			return fmt.Errorf("%w: parameter %s=%d out of range [1, %d]", ErrPasswordHash, name, value, maximum)
+		}
+		return nil
+	}
+	var errs []error
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		errs = append(errs, limit("m", int64(h.Argon2Memory), passwordMaxArgon2Memory),
+			limit("t", int64(h.Argon2Time), passwordMaxArgon2Time))
+	case PasswordBcrypt:
+		errs = append(errs, limit("cost", int64(h.BcryptCost), passwordMaxBcryptCost))
+	case PasswordScrypt:
+		if h.ScryptN < 2 || h.ScryptN&(h.ScryptN-1) != 0 || h.ScryptN > 1<<passwordMaxScryptLogN {
+			errs = append(errs, fmt.Errorf("%w: parameter N=%d must be a power of 2 up to 2^%d", ErrPasswordHash, h.ScryptN, passwordMaxScryptLogN))
+		}
+		errs = append(errs, limit("r", int64(h.ScryptR), passwordMaxScryptR), limit("p", int64(h.ScryptP), passwordMaxScryptP))
+		if int64(h.ScryptN)*int64(h.ScryptR)*128 > passwordMaxScryptMemory {
+			errs = append(errs, fmt.Errorf("%w: scrypt memory N=%d,r=%d exceeds %d bytes", ErrPasswordHash, h.ScryptN, h.ScryptR, passwordMaxScryptMemory))
+		}
+	case PasswordPBKDF2:
+		errs = append(errs, limit("i", int64(h.PBKDF2Iterations), passwordMaxPBKDF2Iter))
+	default:
+		return fmt.Errorf("%w: %q", ErrPasswordAlgorithm, h.Algorithm)
+	}
+	if h.Algorithm != PasswordBcrypt {
+		errs = append(errs, limit("salt length", int64(h.SaltLength), passwordMaxLength),
+			limit("key length", int64(h.KeyLength), passwordMaxLength))
+	}
+	return errors.Join(errs...)
+}
+
+// params 生成 PHC 参数段
+func (h PasswordHasher) params() string {
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		return fmt.Sprintf("v=%d$m=%d,t=%d,p=%d", argon2.Version, h.Argon2Memory, h.Argon2Time, h.Argon2Threads)
+	case PasswordScrypt:
+		return fmt.Sprintf("ln=%d,r=%d,p=%d", bits.Len(uint(h.ScryptN))-1, h.ScryptR, h.ScryptP)
+	case PasswordPBKDF2:
+		return fmt.Sprintf("i=%d", h.PBKDF2Iterations)
+	}
+	return ""
+}
+
+// derive 按参数计算口令哈希
+func (h PasswordHasher) derive(password string, salt []byte) ([]byte, error) {
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		return argon2.IDKey([]byte(password), salt, h.Argon2Time, h.Argon2Memory, h.Argon2Threads, uint32(h.KeyLength)), nil
+	case PasswordScrypt:
+		return scrypt.Key([]byte(password), salt, h.ScryptN, h.ScryptR, h.ScryptP, h.KeyLength)
+	case PasswordPBKDF2:
+		return pbkdf2.Key(sha256.New, password, salt, h.PBKDF2Iterations, h.KeyLength)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrPasswordAlgorithm, h.Algorithm)
+}
+
+// passwordParse 解析 PHC 格式口令哈希
+func passwordParse(encoded string) (*passwordParams, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 5 || parts[0] != "" {
+		return nil, ErrPasswordHash
+	}
+	h := PasswordHasher{Algorithm: PasswordAlgorithm(parts[1])}
+	fields := parts[2 : len(parts)-2]
+	var names []string
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		if len(fields) != 2 || fields[0] != "v="+strconv.Itoa(argon2.Version) {
+			return nil, fmt.Errorf("%w: unsupported argon2 version", ErrPasswordHash)
+		}
+		names = []string{"m", "t", "p"}
+	case PasswordScrypt:
+		names = []string{"ln", "r", "p"}
+	case PasswordPBKDF2:
+		names = []string{"i"}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrPasswordAlgorithm, parts[1])
+	}
+	if h.Algorithm != PasswordArgon2id && len(fields) != 1 {
+		return nil, ErrPasswordHash
+	}
+	values, err := passwordParseParams(fields[len(fields)-1], names)
+	if err != nil {
+		return nil, err
+	}
+	switch h.Algorithm {
+	case PasswordArgon2id:
+		if values["p"] > 255 {
+			return nil, fmt.Errorf("%w: parameter p=%d", ErrPasswordHash, values["p"])
+		}
+		h.Argon2Memory, h.Argon2Time, h.Argon2Threads = uint32(values["m"]), uint32(values["t"]), uint8(values["p"])
+	case PasswordScrypt:
+		if values["ln"] > passwordMaxScryptLogN {
+			return nil, fmt.Errorf("%w: parameter ln=%d exceeds %d", ErrPasswordHash, values["ln"], passwordMaxScryptLogN)
+		}
+		h.ScryptN, h.ScryptR, h.ScryptP = 1<<values["ln"], int(values["r"]), int(values["p"])
+	case PasswordPBKDF2:
+		h.PBKDF2Iterations = int(values["i"])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[len(parts)-2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: salt: %v", ErrPasswordHash, err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[len(parts)-1])
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("%w: invalid hash value", ErrPasswordHash)
+	}
+	h = h.normalize()
+	h.SaltLength, h.KeyLength = len(salt), len(key)
+	if err = h.check(); err != nil {
+		return nil, err
+	}
+	return &passwordParams{hasher: h, salt: salt, key: key}, nil
+}
+
+// passwordParseParams 解析 k=v,k=v 参数段，参数须与 names 一一对应且为正整数
+func passwordParseParams(field string, names []string) (map[string]uint64, error) {
+	values := make(map[string]uint64, len(names))
+	for _, item := range strings.Split(field, ",") {
+		name, value, _ := strings.Cut(item, "=")
+		num, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || num == 0 || !slices.Contains(names, name) {
+			return nil, fmt.Errorf("%w: parameter %q", ErrPasswordHash, item)
+		}
+		if _, ok := values[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate parameter %q", ErrPasswordHash, name)
+		}
+		values[name] = num
+	}
+	if len(values) != len(names) {
+		return nil, fmt.Errorf("%w: parameters %q", ErrPasswordHash, field)
+	}
+	return values, nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// 测试用低成本参数
+var passwordTestHashers = []PasswordHasher{
+	{Algorithm: PasswordArgon2id, Argon2Memory: 64, Argon2Time: 1},
+	{Algorithm: PasswordBcrypt, BcryptCost: 4},
+	{Algorithm: PasswordScrypt, ScryptN: 1024, ScryptR: 8, ScryptP: 1},
+	{Algorithm: PasswordPBKDF2, PBKDF2Iterations: 1000},
+}
+
+func TestPasswordHashVerify(t *testing.T) {
+	for _, h := range passwordTestHashers {
+		encoded, err := h.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", h.Algorithm, err)
+		}
+		if ok, err := PasswordVerify("correct horse", encoded); !ok || err != nil {
+			t.Fatalf("%s: PasswordVerify = %v, %v", h.Algorithm, ok, err)
+		}
+		if ok, err := PasswordVerify("wrong horse", encoded); ok || err != nil {
+			t.Fatalf("%s: wrong password = %v, %v", h.Algorithm, ok, err)
+		}
+		if h.NeedsRehash(encoded) {
+			t.Fatalf("%s: NeedsRehash with same parameters", h.Algorithm)
+		}
+		if !PasswordNeedsRehash(encoded) {
+			t.Fatalf("%s: PasswordNeedsRehash with default parameters", h.Algorithm)
+		}
+	}
+}
+
+// golang.org/x/crypto/argon2 已知答案：argon2id, t=1, m=64, p=1
+func TestPasswordVerifyArgon2Vector(t *testing.T) {
+	b64 := base64.RawStdEncoding
+	key := smHex(t, "655ad15eac652dc59f7170a7332bf49b8469be1fdb9c28bb")
+	encoded := "$argon2id$v=19$m=64,t=1,p=1$" + b64.EncodeToString([]byte("somesalt")) + "$" + b64.EncodeToString(key)
+	if ok, err := PasswordVerify("password", encoded); !ok || err != nil {
+		t.Fatalf("PasswordVerify = %v, %v", ok, err)
+	}
+}
+
+func TestPasswordVerifyBcryptTooLong(t *testing.T) {
+	encoded, err := PasswordHasher{Algorithm: PasswordBcrypt, BcryptCost: 4}.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := PasswordVerify(strings.Repeat("a", 100), encoded); ok || err != nil {
+		t.Fatalf("PasswordVerify = %v, %v", ok, err)
+	}
+}
+
+func TestPasswordVerifyLimits(t *testing.T) {
+	salt, key := "c29tZXNhbHQ", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	bcryptHash, err := PasswordHasher{Algorithm: PasswordBcrypt, BcryptCost: 4}.Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []string{
+		"$pbkdf2-sha256$i=4294967295$" + salt + "$" + key,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=4294967295,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + key,
+		"$scrypt$ln=30,r=8,p=1$" + salt + "$" + key,
+		"$scrypt$ln=20,r=32,p=1$" + salt + "$" + key,
+		"$scrypt$ln=10,r=8,p=4294967295$" + salt + "$" + key,
+		"$scrypt$ln=10,r=4294967295,p=1$" + salt + "$" + key,
+		strings.Replace(bcryptHash, "$04$", "$31$", 1),
+		"$pbkdf2-sha256$i=1000$" + salt + "$" + strings.Repeat("A", 2000),
+	}
+	for _, encoded := range tests {
+		if ok, err := PasswordVerify("secret", encoded); ok || !errors.Is(err, ErrPasswordHash) {
+			t.Errorf("PasswordVerify(%.60s) = %v, %v", encoded, ok, err)
+		}
+	}
+}
+
+func TestPasswordHashLimits(t *testing.T) {
+	tests := []PasswordHasher{
+		{Algorithm: PasswordArgon2id, Argon2Memory: passwordMaxArgon2Memory + 1},
+		{Algorithm: PasswordBcrypt, BcryptCost: passwordMaxBcryptCost + 1},
+		{Algorithm: PasswordScrypt, ScryptN: 1000},
+		{Algorithm: PasswordScrypt, ScryptN: 1 << 21},
+		{Algorithm: PasswordPBKDF2, PBKDF2Iterations: passwordMaxPBKDF2Iter + 1},
+	}
+	for _, h := range tests {
+		if _, err := h.Hash("secret"); !errors.Is(err, ErrPasswordHash) {
+			t.Errorf("%+v: err = %v", h, err)
+		}
+	}
+	if _, err := (PasswordHasher{Algorithm: "md5"}).Hash("secret"); !errors.Is(err, ErrPasswordAlgorithm) {
+		t.Errorf("unsupported algorithm: err = %v", err)
+	}
+}
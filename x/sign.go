@@ -0,0 +1,362 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var (
+	ErrKeyFormat = errors.New("x: invalid key format")  // 密钥格式错误
+	ErrKeyType   = errors.New("x: unexpected key type") // 密钥类型与算法不匹配
+)
+
+// PrivateKeyFromPEM 由 PEM 导入私钥，支持 PKCS#1（RSA PRIVATE KEY）、PKCS#8（PRIVATE KEY）及 SEC 1（EC PRIVATE KEY），
+// 返回 *rsa.PrivateKey、*ecdsa.PrivateKey 或 ed25519.PrivateKey
+func PrivateKeyFromPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrKeyFormat)
+	}
+	switch block.Type {
+	case "RSA PRIVATE KEY", "PRIVATE KEY", "EC PRIVATE KEY":
+		return PrivateKeyFromDER(block.Bytes)
+	}
+	return nil, fmt.Errorf("%w: unsupported PEM type %q", ErrKeyFormat, block.Type)
+}
+
+// PrivateKeyFromDER 由 DER 导入私钥，依次尝试 PKCS#8、PKCS#1 及 SEC 1 格式
+func PrivateKeyFromDER(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("%w: %T", ErrKeyType, key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: not a PKCS#8, PKCS#1 or SEC 1 private key", ErrKeyFormat)
+}
+
+// PrivateKeyFromBase64 导入 Base64 编码的 DER 私钥，例：支付平台提供的不含 PEM 头尾的私钥字符串，同时兼容 PEM 内容
+func PrivateKeyFromBase64(data string) (crypto.Signer, error) {
+	if strings.Contains(data, "-----BEGIN") {
+		return PrivateKeyFromPEM([]byte(data))
+	}
+	der, err := base64.StdEncoding.DecodeString(keyBase64Clean(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFormat, err)
+	}
+	return PrivateKeyFromDER(der)
+}
+
+// PrivateKeyFromPKCS12 由 PKCS#12（.p12/.pfx）内容导入私钥及证书，包含证书链时返回与私钥匹配的终端证书
+func PrivateKeyFromPKCS12(data []byte, password string) (crypto.Signer, *x509.Certificate, error) {
+	signer, leaf, _, err := pkcs12DecodeLeaf(data, password)
+	return signer, leaf, err
+}
+
+// pkcs12DecodeLeaf 解析 PKCS#12 内容，返回私钥、与私钥匹配的终端证书及其余证书。
+// 部分工具导出时证书顺序不固定，首个证书未必是终端证书，均不匹配时返回首个证书
+func pkcs12DecodeLeaf(data []byte, password string) (crypto.Signer, *x509.Certificate, []*x509.Certificate, error) {
+	key, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrKeyFormat, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %T", ErrKeyType, key)
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if ok && !pub.Equal(cert.PublicKey) {
+		for i, ca := range caCerts {
+			if pub.Equal(ca.PublicKey) {
+				chain := append([]*x509.Certificate{cert}, caCerts[:i]...)
+				return signer, ca, append(chain, caCerts[i+1:]...), nil
+			}
+		}
+	}
+	return signer, cert, caCerts, nil
+}
+
+// PublicKeyFromPEM 由 PEM 导入公钥，支持 PKIX（PUBLIC KEY）、PKCS#1（RSA PUBLIC KEY）及证书（CERTIFICATE），
+// 返回 *rsa.PublicKey、*ecdsa.PublicKey 或 ed25519.PublicKey
+func PublicKeyFromPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrKeyFormat)
+	}
+	switch block.Type {
+	case "PUBLIC KEY", "RSA PUBLIC KEY":
+		return PublicKeyFromDER(block.Bytes)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrKeyFormat, err)
+		}
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported PEM type %q", ErrKeyFormat, block.Type)
+}
+
+// PublicKeyFromDER 由 DER 导入公钥，依次尝试 PKIX、PKCS#1 及 X.509 证书格式
+func PublicKeyFromDER(der []byte) (crypto.PublicKey, error) {
+	if key, err := x509.ParsePKIXPublicKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(der); err == nil {
+		return cert.PublicKey, nil
+	}
+	return nil, fmt.Errorf("%w: not a PKIX, PKCS#1 public key or certificate", ErrKeyFormat)
+}
+
+// PublicKeyFromBase64 导入 Base64 编码的 DER 公钥或证书，例：支付平台提供的不含 PEM 头尾的公钥字符串，同时兼容 PEM 内容
+func PublicKeyFromBase64(data string) (crypto.PublicKey, error) {
+	if strings.Contains(data, "-----BEGIN") {
+		return PublicKeyFromPEM([]byte(data))
+	}
+	der, err := base64.StdEncoding.DecodeString(keyBase64Clean(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyFormat, err)
+	}
+	return PublicKeyFromDER(der)
+}
+
+// PrivateKeyToPEM 导出 PKCS#8 PEM 私钥
+func PrivateKeyToPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// PublicKeyToPEM 导出 PKIX PEM 公钥
+func PublicKeyToPEM(key crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// RSAGenerateKey 生成 RSA 私钥，bits 建议 2048 及以上
+func RSAGenerateKey(bits int) (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, bits)
+}
+
+// ECDSAGenerateKey 生成 ECDSA P-256 私钥
+func ECDSAGenerateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// Ed25519GenerateKey 生成 Ed25519 私钥
+func Ed25519GenerateKey() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+// RSASignPKCS1v15 RSA 签名 PKCS#1 v1.5，例：hash 为 crypto.SHA256 即 RSA-SHA256（SHA256WithRSA），返回 Base64 签名
+func RSASignPKCS1v15(key crypto.Signer, hash crypto.Hash, data []byte) (string, error) {
+	priv, err := rsaPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	digest, err := signDigest(hash, data)
+	if err != nil {
+		return "", err
+	}
+	sig, err := rsa.SignPKCS1v15(rand.Reader, priv, hash, digest)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// RSAVerifyPKCS1v15 RSA 验签 PKCS#1 v1.5，sig 为 Base64 签名
+func RSAVerifyPKCS1v15(key crypto.PublicKey, hash crypto.Hash, data []byte, sig string) bool {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return false
+	}
+	digest, err := signDigest(hash, data)
+	if err != nil {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return rsa.VerifyPKCS1v15(pub, hash, digest, raw) == nil
+}
+
+// RSASignPSS RSA 签名 PSS，盐长度与摘要长度相同，返回 Base64 签名
+func RSASignPSS(key crypto.Signer, hash crypto.Hash, data []byte) (string, error) {
+	priv, err := rsaPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	digest, err := signDigest(hash, data)
+	if err != nil {
+		return "", err
+	}
+	sig, err := rsa.SignPSS(rand.Reader, priv, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// RSAVerifyPSS RSA 验签 PSS，自动识别盐长度，sig 为 Base64 签名
+func RSAVerifyPSS(key crypto.PublicKey, hash crypto.Hash, data []byte, sig string) bool {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return false
+	}
+	digest, err := signDigest(hash, data)
+	if err != nil {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return rsa.VerifyPSS(pub, hash, digest, raw, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+}
+
+// RSAEncryptOAEP RSA 公钥加密 OAEP，hash 同时用于 MGF1，例：crypto.SHA256，对接部分支付平台须使用 crypto.SHA1，返回 Base64 密文
+func RSAEncryptOAEP(key crypto.PublicKey, hash crypto.Hash, plain []byte) (string, error) {
+	pub, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return "", fmt.Errorf("%w: RSA public key required, got %T", ErrKeyType, key)
+	}
+	if !hash.Available() {
+		return "", fmt.Errorf("x: hash function %v is not available", hash)
+	}
+	crypted, err := rsa.EncryptOAEP(hash.New(), rand.Reader, pub, plain, nil)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(crypted), nil
+}
+
+// RSADecryptOAEP RSA 私钥解密 OAEP，data 为 Base64 密文，hash 须与加密时一致
+func RSADecryptOAEP(key crypto.Signer, hash crypto.Hash, data string) ([]byte, error) {
+	priv, err := rsaPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if !hash.Available() {
+		return nil, fmt.Errorf("x: hash function %v is not available", hash)
+	}
+	crypted, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	return rsa.DecryptOAEP(hash.New(), nil, priv, crypted, nil)
+}
+
+// ECDSASign ECDSA-SHA256 签名（SHA256WithECDSA），返回 Base64 编码的 ASN.1 DER 签名
+func ECDSASign(key crypto.Signer, data []byte) (string, error) {
+	priv, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("%w: ECDSA private key required, got %T", ErrKeyType, key)
+	}
+	digest := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// ECDSAVerify ECDSA-SHA256 验签，sig 为 Base64 编码的 ASN.1 DER 签名
+func ECDSAVerify(key crypto.PublicKey, data []byte, sig string) bool {
+	pub, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256(data)
+	return ecdsa.VerifyASN1(pub, digest[:], raw)
+}
+
+// Ed25519Sign Ed25519 签名，返回 Base64 签名
+func Ed25519Sign(key crypto.Signer, data []byte) (string, error) {
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("%w: Ed25519 private key required, got %T", ErrKeyType, key)
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)), nil
+}
+
+// Ed25519Verify Ed25519 验签，sig 为 Base64 签名
+func Ed25519Verify(key crypto.PublicKey, data []byte, sig string) bool {
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pub, data, raw)
+}
+
+// rsaPrivateKey 校验并转换 RSA 私钥
+func rsaPrivateKey(key crypto.Signer) (*rsa.PrivateKey, error) {
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: RSA private key required, got %T", ErrKeyType, key)
+	}
+	return priv, nil
+}
+
+// signDigest 计算签名摘要
+func signDigest(hash crypto.Hash, data []byte) ([]byte, error) {
+	if !hash.Available() {
+		return nil, fmt.Errorf("x: hash function %v is not available", hash)
+	}
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// keyBase64Clean 去除 Base64 密钥中的空白及换行
+func keyBase64Clean(data string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, data)
+}
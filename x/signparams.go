@@ -0,0 +1,207 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// signParamsOptions 参数签名选项
+type signParamsOptions struct {
+	field     string
+	skip      []string
+	secretKey string
+	hash      func(plain string) string
+	upper     bool
+}
+
+// SignParamsOption 参数签名选项
+type SignParamsOption func(*signParamsOptions)
+
+// SignWithField 设置签名字段名称，默认 sign，签名字段不参与签名
+func SignWithField(name string) SignParamsOption {
+	return func(o *signParamsOptions) {
+		o.field = name
+	}
+}
+
+// SignWithSkip 设置其他不参与签名的字段，例：sign_type
+func SignWithSkip(fields ...string) SignParamsOption {
+	return func(o *signParamsOptions) {
+		o.skip = append(o.skip, fields...)
+	}
+}
+
+// SignWithSecretKey 以 &name=secret 形式追加密钥，例：name 为 key 时追加 &key=secret，默认直接追加密钥
+func SignWithSecretKey(name string) SignParamsOption {
+	return func(o *signParamsOptions) {
+		o.secretKey = name
+	}
+}
+
+// SignWithHash 设置摘要函数，默认 MD5String，例：func(s string) string { return x.HMACSHA256String(secret, s) }
+func SignWithHash(hash func(plain string) string) SignParamsOption {
+	return func(o *signParamsOptions) {
+		o.hash = hash
+	}
+}
+
+// SignWithUpper 签名结果转为大写
+func SignWithUpper() SignParamsOption {
+	return func(o *signParamsOptions) {
+		o.upper = true
+	}
+}
+
+// SignParamsString 生成待签名字符串：按键名升序排列，跳过空值及签名字段，以 k=v&k=v 拼接，不含密钥。
+// params 支持 map[string]string、map[string]any、url.Values 等，url.Values 取每个键的第一个值，
+// 映射、切片及结构体值按 JSON 序列化，可用于 RSA 等非对称签名
+func SignParamsString[M ~map[string]string | ~map[string]any | ~map[string][]string](params M, opts ...SignParamsOption) string {
+	return signParamsString(reflect.ValueOf(params), signParamsOpts(opts))
+}
+
+// SignParams 参数签名：待签名字符串追加密钥后计算摘要，默认 MD5 小写十六进制，
+// 例：微信支付 V2 为 SignParams(params, key, x.SignWithSecretKey("key"), x.SignWithUpper())
+func SignParams[M ~map[string]string | ~map[string]any | ~map[string][]string](params M, secret string, opts ...SignParamsOption) string {
+	o := signParamsOpts(opts)
+	return signParams(signParamsString(reflect.ValueOf(params), o), secret, o)
+}
+
+// VerifyParams 校验参数签名，sign 为空时取参数中签名字段的值，以常量时间比较，
+// 两者均为十六进制时忽略大小写，其他格式（例：Base64）按原样比较
+func VerifyParams[M ~map[string]string | ~map[string]any | ~map[string][]string](params M, secret string, sign string, opts ...SignParamsOption) bool {
+	o := signParamsOpts(opts)
+	m := reflect.ValueOf(params)
+	if sign == "" {
+		if val := m.MapIndex(reflect.ValueOf(o.field)); val.IsValid() {
+			sign = signParamValue(val)
+		}
+	}
+	if sign == "" {
+		return false
+	}
+	expected := signParams(signParamsString(m, o), secret, o)
+	if signIsHex(expected) && signIsHex(sign) {
+		expected, sign = strings.ToLower(expected), strings.ToLower(sign)
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(sign)) == 1
+}
+
+// signIsHex 是否为十六进制字符串
+func signIsHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// signParamsOpts 合并签名选项
+func signParamsOpts(opts []SignParamsOption) *signParamsOptions {
+	o := &signParamsOptions{field: "sign", hash: MD5String}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// signParams 追加密钥并计算摘要
+func signParams(plain string, secret string, o *signParamsOptions) string {
+	if o.secretKey != "" {
+		if plain != "" {
+			plain += "&"
+		}
+		plain += o.secretKey + "=" + secret
+	} else {
+		plain += secret
+	}
+	sign := o.hash(plain)
+	if o.upper {
+		sign = strings.ToUpper(sign)
+	}
+	return sign
+}
+
+// signParamsString 排序并拼接参数
+func signParamsString(m reflect.Value, o *signParamsOptions) string {
+	keys := make([]string, 0, m.Len())
+	values := make(map[string]string, m.Len())
+	for iter := m.MapRange(); iter.Next(); {
+		key := iter.Key().String()
+		if key == o.field || slices.Contains(o.skip, key) {
+			continue
+		}
+		if val := signParamValue(iter.Value()); val != "" {
+			keys = append(keys, key)
+			values[key] = val
+		}
+	}
+	slices.Sort(keys)
+	var sb strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(values[key])
+	}
+	return sb.String()
+}
+
+// signParamValue 参数值转为字符串，数值不做精度截断，nil（包括 nil 指针）及空切片返回空字符串
+func signParamValue(v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+	}
+	switch val := v.Interface().(type) {
+	case string:
+		return val
+	case []string:
+		if len(val) == 0 {
+			return ""
+		}
+		return val[0]
+	case json.Number:
+		return val.String()
+	case fmt.Stringer:
+		return val.String()
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	}
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Array, reflect.Struct:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v.Interface()); err != nil {
+			return ""
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+	return fmt.Sprint(v.Interface())
+}
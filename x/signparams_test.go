@@ -0,0 +1,54 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSignParamsString(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	params := map[string]any{
+		"b":      2,
+		"a":      "x",
+		"amount": 0.1,
+		"big":    big.NewInt(12345678901234),
+		"num":    json.Number("100000000000000000001"),
+		"list":   []int{1, 2},
+		"html":   map[string]string{"k": "<&>"},
+		"time":   &at,
+		"empty":  "",
+		"nil":    nil,
+		"sign":   "ignored",
+		// nil 指针实现了 fmt.Stringer，不能调用 String 方法
+		"nilTime":  (*time.Time)(nil),
+		"nilBig":   (*big.Int)(nil),
+		"nilMap":   map[string]any(nil),
+		"nilSlice": []string(nil),
+	}
+	want := `a=x&amount=0.1&b=2&big=12345678901234&html={"k":"<&>"}&list=[1,2]&num=100000000000000000001&time=2026-01-02 03:04:05 +0000 UTC`
+	if got := SignParamsString(params); got != want {
+		t.Fatalf("SignParamsString = %s, want %s", got, want)
+	}
+}
+
+func TestSignParamsVerify(t *testing.T) {
+	params := url.Values{"appid": {"wx1"}, "body": {"test"}, "nonce_str": {"abc"}}
+	sign := SignParams(params, "secret", SignWithSecretKey("key"), SignWithUpper())
+	params.Set("sign", sign)
+	if !VerifyParams(params, "secret", "", SignWithSecretKey("key")) {
+		t.Fatal("VerifyParams failed")
+	}
+	if VerifyParams(params, "wrong", "", SignWithSecretKey("key")) {
+		t.Fatal("VerifyParams accepted wrong secret")
+	}
+}
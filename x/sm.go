@@ -0,0 +1,342 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	smcipher "github.com/emmansun/gmsm/cipher"
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+var (
+	ErrSM4KeySize    = errors.New("x: invalid SM4 key size, must be 16 bytes") // SM4 密钥长度错误
+	ErrSM4IVSize     = errors.New("x: invalid SM4 iv size, must be 16 bytes")  // SM4 向量长度错误
+	ErrSM4Ciphertext = errors.New("x: invalid SM4 ciphertext")                 // SM4 密文格式错误
+	ErrSM4Padding    = errors.New("x: invalid SM4 padding")                    // SM4 填充错误，密钥错误或密文损坏
+	ErrSM2Key        = errors.New("x: invalid SM2 key")                        // SM2 密钥格式错误
+	ErrSM2Decrypt    = errors.New("x: SM2 decryption failed")                  // SM2 解密失败，密钥错误或密文损坏
+)
+
+// SM4EncryptE SM4 加密内容 SM4/CBC/PKCS7Padding，key：16，iv：16，返回 Base64 密文及错误
+func SM4EncryptE(key string, iv string, data string) (string, error) {
+	crypted, err := SM4CBCEncrypt([]byte(key), []byte(iv), []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(crypted), nil
+}
+
+// SM4DecryptE SM4 解密 Base64 密文 SM4/CBC/PKCS7Padding，返回明文及错误
+func SM4DecryptE(key string, iv string, data string) (string, error) {
+	crypted, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSM4Ciphertext, err)
+	}
+	plain, err := SM4CBCDecrypt([]byte(key), []byte(iv), crypted)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// SM4EncryptHex SM4 加密内容 SM4/CBC/PKCS7Padding，返回十六进制密文及错误
+func SM4EncryptHex(key string, iv string, data string) (string, error) {
+	crypted, err := SM4CBCEncrypt([]byte(key), []byte(iv), []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(crypted), nil
+}
+
+// SM4DecryptHex SM4 解密十六进制密文 SM4/CBC/PKCS7Padding，返回明文及错误
+func SM4DecryptHex(key string, iv string, data string) (string, error) {
+	crypted, err := hex.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSM4Ciphertext, err)
+	}
+	plain, err := SM4CBCDecrypt([]byte(key), []byte(iv), crypted)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// SM4ECBEncrypt SM4 加密 SM4/ECB/PKCS7Padding，key：16。ECB 模式相同明文分组产生相同密文，仅用于对接既有系统
+func SM4ECBEncrypt(key, plain []byte) ([]byte, error) {
+	block, err := sm4Block(key)
+	if err != nil {
+		return nil, err
+	}
+	content := pkcs7Pad(plain, sm4.BlockSize)
+	smcipher.NewECBEncrypter(block).CryptBlocks(content, content)
+	return content, nil
+}
+
+// SM4ECBDecrypt SM4 解密 SM4/ECB/PKCS7Padding，key：16
+func SM4ECBDecrypt(key, crypted []byte) ([]byte, error) {
+	block, err := sm4Block(key)
+	if err != nil {
+		return nil, err
+	}
+	if err = sm4CheckCiphertext(crypted); err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(crypted))
+	smcipher.NewECBDecrypter(block).CryptBlocks(decrypted, crypted)
+	return sm4Unpad(decrypted)
+}
+
+// SM4CBCEncrypt SM4 加密 SM4/CBC/PKCS7Padding，key：16，iv：16
+func SM4CBCEncrypt(key, iv, plain []byte) ([]byte, error) {
+	block, err := sm4Block(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != sm4.BlockSize {
+		return nil, fmt.Errorf("%w: got %d", ErrSM4IVSize, len(iv))
+	}
+	content := pkcs7Pad(plain, sm4.BlockSize)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(content, content)
+	return content, nil
+}
+
+// SM4CBCDecrypt SM4 解密 SM4/CBC/PKCS7Padding，key：16，iv：16，填充以常量时间校验，校验失败时返回 ErrSM4Padding
+func SM4CBCDecrypt(key, iv, crypted []byte) ([]byte, error) {
+	block, err := sm4Block(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != sm4.BlockSize {
+		return nil, fmt.Errorf("%w: got %d", ErrSM4IVSize, len(iv))
+	}
+	if err = sm4CheckCiphertext(crypted); err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, len(crypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, crypted)
+	return sm4Unpad(decrypted)
+}
+
+// SM4GCMEncrypt SM4-GCM 加密，key：16，返回 随机 nonce（12）+ 密文 + 认证标签（16），aad 为可选附加数据
+func SM4GCMEncrypt(key, plain, aad []byte) ([]byte, error) {
+	return aeadSeal(AEADSM4GCM, key, plain, aad)
+}
+
+// SM4GCMDecrypt SM4-GCM 解密 SM4GCMEncrypt 的结果，aad 须与加密时一致，认证失败时返回 ErrAEADDecrypt
+func SM4GCMDecrypt(key, data, aad []byte) ([]byte, error) {
+	return aeadOpen(AEADSM4GCM, key, data, aad)
+}
+
+// sm4Block 校验密钥长度并创建 SM4 分组
+func sm4Block(key []byte) (cipher.Block, error) {
+	if len(key) != sm4.BlockSize {
+		return nil, fmt.Errorf("%w: got %d", ErrSM4KeySize, len(key))
+	}
+	return sm4.NewCipher(key)
+}
+
+// sm4CheckCiphertext 校验密文长度为分组长度的整数倍
+func sm4CheckCiphertext(crypted []byte) error {
+	if len(crypted) == 0 || len(crypted)%sm4.BlockSize != 0 {
+		return fmt.Errorf("%w: length %d is not a multiple of %d", ErrSM4Ciphertext, len(crypted), sm4.BlockSize)
+	}
+	return nil
+}
+
+// sm4Unpad 去除 PKCS#7 填充
+func sm4Unpad(decrypted []byte) ([]byte, error) {
+	plain, err := pkcs7Unpad(decrypted, sm4.BlockSize)
+	if err != nil {
+		return nil, ErrSM4Padding
+	}
+	return plain, nil
+}
+
+// SM2CipherMode SM2 密文格式
+type SM2CipherMode int
+
+const (
+	SM2C1C3C2 SM2CipherMode = iota // C1||C3||C2，GB/T 32918.4-2016 新标准格式
+	SM2C1C2C3                      // C1||C2||C3，旧标准格式，部分早期系统使用
+	SM2ASN1                        // ASN.1 DER 编码，GM/T 0009
+)
+
+// SM2GenerateKey 生成 SM2 密钥对
+func SM2GenerateKey() (*sm2.PrivateKey, error) {
+	return sm2.GenerateKey(rand.Reader)
+}
+
+// SM2PrivateKeyFromHex 由十六进制私钥（32 字节）导入 SM2 私钥
+func SM2PrivateKeyFromHex(key string) (*sm2.PrivateKey, error) {
+	data, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+	}
+	priv, err := sm2.NewPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+	}
+	return priv, nil
+}
+
+// SM2PublicKeyFromHex 由十六进制公钥导入 SM2 公钥，支持 04||X||Y（65 字节）及省略 04 前缀的 X||Y（64 字节）
+func SM2PublicKeyFromHex(key string) (*ecdsa.PublicKey, error) {
+	data, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+	}
+	if len(data) == 64 {
+		data = append([]byte{0x04}, data...)
+	}
+	pub, err := sm2.NewPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+	}
+	return pub, nil
+}
+
+// SM2PrivateKeyFromPEM 由 PEM 导入 SM2 私钥，支持 PKCS#8（PRIVATE KEY）及 SEC 1（EC PRIVATE KEY）格式
+func SM2PrivateKeyFromPEM(data []byte) (*sm2.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrSM2Key)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := smx509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+		}
+		priv, ok := key.(*sm2.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: not an SM2 private key: %T", ErrSM2Key, key)
+		}
+		return priv, nil
+	case "EC PRIVATE KEY", "SM2 PRIVATE KEY":
+		priv, err := smx509.ParseSM2PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+		}
+		return priv, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported PEM type %q", ErrSM2Key, block.Type)
+}
+
+// SM2PublicKeyFromPEM 由 PEM 导入 SM2 公钥，支持 PKIX（PUBLIC KEY）及证书（CERTIFICATE）
+func SM2PublicKeyFromPEM(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrSM2Key)
+	}
+	var key any
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := smx509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+		}
+		key = pub
+	case "CERTIFICATE":
+		cert, err := smx509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSM2Key, err)
+		}
+		key = cert.PublicKey
+	default:
+		return nil, fmt.Errorf("%w: unsupported PEM type %q", ErrSM2Key, block.Type)
+	}
+	if !sm2.IsSM2PublicKey(key) {
+		return nil, fmt.Errorf("%w: not an SM2 public key: %T", ErrSM2Key, key)
+	}
+	return key.(*ecdsa.PublicKey), nil
+}
+
+// SM2PrivateKeyToHex 导出十六进制私钥（32 字节）
+func SM2PrivateKeyToHex(priv *sm2.PrivateKey) string {
+	return hex.EncodeToString(priv.D.FillBytes(make([]byte, 32)))
+}
+
+// SM2PublicKeyToHex 导出十六进制公钥 04||X||Y（65 字节）
+func SM2PublicKeyToHex(pub *ecdsa.PublicKey) string {
+	data := make([]byte, 65)
+	data[0] = 0x04
+	pub.X.FillBytes(data[1:33])
+	pub.Y.FillBytes(data[33:])
+	return hex.EncodeToString(data)
+}
+
+// SM2PrivateKeyToPEM 导出 PKCS#8 PEM 私钥
+func SM2PrivateKeyToPEM(priv *sm2.PrivateKey) ([]byte, error) {
+	der, err := smx509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// SM2PublicKeyToPEM 导出 PKIX PEM 公钥
+func SM2PublicKeyToPEM(pub *ecdsa.PublicKey) ([]byte, error) {
+	der, err := smx509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// SM2Sign SM2 签名（SM3 摘要），返回 ASN.1 DER 编码的签名，uid 为空时使用默认用户标识 1234567812345678
+func SM2Sign(priv *sm2.PrivateKey, msg, uid []byte) ([]byte, error) {
+	return priv.SignWithSM2(rand.Reader, uid, msg)
+}
+
+// SM2Verify SM2 验签，sig 为 ASN.1 DER 编码的签名，uid 须与签名时一致
+func SM2Verify(pub *ecdsa.PublicKey, msg, sig, uid []byte) bool {
+	return sm2.VerifyASN1WithSM2(pub, uid, msg, sig)
+}
+
+// SM2Encrypt SM2 公钥加密，mode 指定密文格式，C1 为非压缩点（04 开头）
+func SM2Encrypt(pub *ecdsa.PublicKey, plain []byte, mode SM2CipherMode) ([]byte, error) {
+	switch mode {
+	case SM2C1C3C2:
+		return sm2.Encrypt(rand.Reader, pub, plain, sm2.NewPlainEncrypterOpts(sm2.MarshalUncompressed, sm2.C1C3C2))
+	case SM2C1C2C3:
+		return sm2.Encrypt(rand.Reader, pub, plain, sm2.NewPlainEncrypterOpts(sm2.MarshalUncompressed, sm2.C1C2C3))
+	case SM2ASN1:
+		return sm2.EncryptASN1(rand.Reader, pub, plain)
+	}
+	return nil, fmt.Errorf("x: unsupported SM2 cipher mode %d", mode)
+}
+
+// SM2Decrypt SM2 私钥解密，mode 须与加密时的密文格式一致，失败时返回 ErrSM2Decrypt
+func SM2Decrypt(priv *sm2.PrivateKey, data []byte, mode SM2CipherMode) ([]byte, error) {
+	var opts *sm2.DecrypterOpts
+	switch mode {
+	case SM2C1C3C2:
+		opts = sm2.NewPlainDecrypterOpts(sm2.C1C3C2)
+	case SM2C1C2C3:
+		opts = sm2.NewPlainDecrypterOpts(sm2.C1C2C3)
+	case SM2ASN1:
+		opts = sm2.ASN1DecrypterOpts
+	default:
+		return nil, fmt.Errorf("x: unsupported SM2 cipher mode %d", mode)
+	}
+	plain, err := priv.Decrypt(nil, data, opts)
+	if err != nil {
+		return nil, ErrSM2Decrypt
+	}
+	return plain, nil
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright © 2021 - 2026 vity <vityme@icloud.com>.
+ *
+ * Use of this source code is governed by an MIT-style
+ * license that can be found in the LICENSE file.
+ */
+
+package x
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func smHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// GM/T 0004-2012 附录 A
+func TestSM3String(t *testing.T) {
+	tests := []struct {
+		plain string
+		want  string
+	}{
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	}
+	for _, tt := range tests {
+		if got := SM3String(tt.plain); got != tt.want {
+			t.Errorf("SM3String(%q) = %s, want %s", tt.plain, got, tt.want)
+		}
+	}
+}
+
+// GM/T 0002-2012 附录 A
+func TestSM4Block(t *testing.T) {
+	key := smHex(t, "0123456789abcdeffedcba9876543210")
+	block, err := sm4Block(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, 16)
+	block.Encrypt(out, key)
+	if got := hex.EncodeToString(out); got != "681edf34d206965e86b3e94f536e4246" {
+		t.Fatalf("encrypt = %s", got)
+	}
+	if testing.Short() {
+		return
+	}
+	copy(out, key)
+	for i := 0; i < 1000000; i++ {
+		block.Encrypt(out, out)
+	}
+	if got := hex.EncodeToString(out); got != "595298c7c6fd271f0402f804c33d3f66" {
+		t.Fatalf("encrypt 1000000 times = %s", got)
+	}
+}
+
+func TestSM4ECB(t *testing.T) {
+	key := smHex(t, "0123456789abcdeffedcba9876543210")
+	crypted, err := SM4ECBEncrypt(key, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(crypted) != 32 || hex.EncodeToString(crypted[:16]) != "681edf34d206965e86b3e94f536e4246" {
+		t.Fatalf("SM4ECBEncrypt = %x", crypted)
+	}
+	plain, err := SM4ECBDecrypt(key, crypted)
+	if err != nil || !bytes.Equal(plain, key) {
+		t.Fatalf("SM4ECBDecrypt = %x, %v", plain, err)
+	}
+}
+
+func TestSM4CBC(t *testing.T) {
+	key, iv := "1234567890abcdef", "fedcba0987654321"
+	crypted, err := SM4EncryptE(key, iv, "hello 国密")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := SM4DecryptE(key, iv, crypted); err != nil || plain != "hello 国密" {
+		t.Fatalf("SM4DecryptE = %q, %v", plain, err)
+	}
+	crypted, err = SM4EncryptHex(key, iv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := SM4DecryptHex(key, iv, crypted); err != nil || plain != "" {
+		t.Fatalf("SM4DecryptHex = %q, %v", plain, err)
+	}
+	if _, err = SM4DecryptHex("1234567890abcdee", iv, crypted); !errors.Is(err, ErrSM4Padding) {
+		t.Fatalf("wrong key: %v", err)
+	}
+	if _, err = SM4EncryptE("short", iv, "x"); !errors.Is(err, ErrSM4KeySize) {
+		t.Fatalf("short key: %v", err)
+	}
+	if _, err = SM4EncryptE(key, "short", "x"); !errors.Is(err, ErrSM4IVSize) {
+		t.Fatalf("short iv: %v", err)
+	}
+}
+
+// RFC 8998 附录 A.1
+func TestSM4GCM(t *testing.T) {
+	key := smHex(t, "0123456789ABCDEFFEDCBA9876543210")
+	aad := smHex(t, "FEEDFACEDEADBEEFFEEDFACEDEADBEEFABADDAD2")
+	plain := smHex(t, "AAAAAAAAAAAAAAAABBBBBBBBBBBBBBBBCCCCCCCCCCCCCCCCDDDDDDDDDDDDDDDDEEEEEEEEEEEEEEEEFFFFFFFFFFFFFFFFEEEEEEEEEEEEEEEEAAAAAAAAAAAAAAAA")
+	data := smHex(t, "00001234567800000000ABCD"+
+		"17F399F08C67D5EE19D0DC9969C4BB7D5FD46FD3756489069157B282BB200735D82710CA5C22F0CCFA7CBF93D496AC15A56834CBCF98C397B4024A2691233B8D"+
+		"83DE3541E4C2B58177E065A9BF7B62EC")
+	got, err := SM4GCMDecrypt(key, data, aad)
+	if err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("SM4GCMDecrypt = %x, %v", got, err)
+	}
+	if _, err = SM4GCMDecrypt(key, data, nil); !errors.Is(err, ErrAEADDecrypt) {
+		t.Fatalf("wrong aad: %v", err)
+	}
+	crypted, err := SM4GCMEncrypt(key, plain, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err = SM4GCMDecrypt(key, crypted, aad); err != nil || !bytes.Equal(got, plain) {
+		t.Fatalf("round trip = %x, %v", got, err)
+	}
+}
+
+// GM/T 0003.5-2012 附录 A 示例私钥
+const smTestPrivateKey = "3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8"
+
+// GM/T 0003.5-2012 附录 A 数字签名示例，用户标识为默认值 1234567812345678
+func TestSM2VerifyVector(t *testing.T) {
+	priv, err := SM2PrivateKeyFromHex(smTestPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := SM2PublicKeyFromHex("0409F9DF311E5421A150DD7D161E4BC5C672179FAD1833FC076BB08FF356F35020CCEA490CE26775A52DC6EA718CC1AA600AED05FBF35E084A6632F6072DA9AD13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		t.Fatal("public key does not match private key")
+	}
+	sig := smHex(t, "3046"+
+		"022100F5A03B0648D2C4630EEAC513E1BB81A15944DA3827D5B74143AC7EACEEE720B3"+
+		"022100B1B6AA29DF212FD8763182BC0D421CA1BB9038FD1F7F42D4840B69C485BBC1AA")
+	if !SM2Verify(pub, []byte("message digest"), sig, nil) {
+		t.Fatal("SM2Verify vector failed")
+	}
+	if SM2Verify(pub, []byte("message digesT"), sig, nil) {
+		t.Fatal("SM2Verify accepted modified message")
+	}
+	if SM2Verify(pub, []byte("message digest"), sig, []byte("ALICE123@YAHOO.COM")) {
+		t.Fatal("SM2Verify accepted different uid")
+	}
+}
+
+// GM/T 0003.5-2012 附录 A 公钥加密示例，密文为 C1||C3||C2
+func TestSM2DecryptVector(t *testing.T) {
+	priv, err := SM2PrivateKeyFromHex(smTestPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crypted := smHex(t, "04"+
+		"04EBFC718E8D1798620432268E77FEB6415E2EDE0E073C0F4F640ECD2E149A73"+
+		"E858F9D81E5430A57B36DAAB8F950A3C64E6EE6A63094D99283AFF767E124DF0"+
+		"59983C18F809E262923C53AEC295D30383B54E39D609D160AFCB1908D0BD8766"+
+		"21886CA989CA9C7D58087307CA93092D651EFA")
+	plain, err := SM2Decrypt(priv, crypted, SM2C1C3C2)
+	if err != nil || string(plain) != "encryption standard" {
+		t.Fatalf("SM2Decrypt = %q, %v", plain, err)
+	}
+}
+
+func TestSM2SignVerify(t *testing.T) {
+	priv, err := SM2GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, uid := []byte("hello"), []byte("user@example.com")
+	sig, err := SM2Sign(priv, msg, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SM2Verify(&priv.PublicKey, msg, sig, uid) {
+		t.Fatal("SM2Verify failed")
+	}
+	if SM2Verify(&priv.PublicKey, msg, sig, nil) {
+		t.Fatal("SM2Verify accepted default uid")
+	}
+}
+
+func TestSM2EncryptModes(t *testing.T) {
+	priv, err := SM2GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("encryption standard")
+	for _, mode := range []SM2CipherMode{SM2C1C3C2, SM2C1C2C3, SM2ASN1} {
+		crypted, err := SM2Encrypt(&priv.PublicKey, msg, mode)
+		if err != nil {
+			t.Fatalf("mode %d: %v", mode, err)
+		}
+		plain, err := SM2Decrypt(priv, crypted, mode)
+		if err != nil || !bytes.Equal(plain, msg) {
+			t.Fatalf("mode %d: SM2Decrypt = %q, %v", mode, plain, err)
+		}
+		crypted[len(crypted)-1] ^= 1
+		if _, err = SM2Decrypt(priv, crypted, mode); !errors.Is(err, ErrSM2Decrypt) {
+			t.Fatalf("mode %d: tampered ciphertext: %v", mode, err)
+		}
+	}
+	c1c3c2, _ := SM2Encrypt(&priv.PublicKey, msg, SM2C1C3C2)
+	if _, err = SM2Decrypt(priv, c1c3c2, SM2C1C2C3); !errors.Is(err, ErrSM2Decrypt) {
+		t.Fatalf("mismatched mode: %v", err)
+	}
+}
+
+func TestSM2KeyEncoding(t *testing.T) {
+	priv, err := SM2GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privPEM, err := SM2PrivateKeyToPEM(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM, err := SM2PublicKeyToPEM(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := SM2PrivateKeyFromPEM(privPEM); err != nil || !key.Equal(priv) {
+		t.Fatalf("SM2PrivateKeyFromPEM: %v", err)
+	}
+	if pub, err := SM2PublicKeyFromPEM(pubPEM); err != nil || !pub.Equal(&priv.PublicKey) {
+		t.Fatalf("SM2PublicKeyFromPEM: %v", err)
+	}
+	if key, err := SM2PrivateKeyFromHex(SM2PrivateKeyToHex(priv)); err != nil || !key.Equal(priv) {
+		t.Fatalf("SM2PrivateKeyFromHex: %v", err)
+	}
+	pubHex := SM2PublicKeyToHex(&priv.PublicKey)
+	for _, s := range []string{pubHex, pubHex[2:]} {
+		if pub, err := SM2PublicKeyFromHex(s); err != nil || !pub.Equal(&priv.PublicKey) {
+			t.Fatalf("SM2PublicKeyFromHex(%s): %v", s, err)
+		}
+	}
+	if _, err = SM2PublicKeyFromHex("04abcd"); !errors.Is(err, ErrSM2Key) {
+		t.Fatalf("invalid public key: %v", err)
+	}
+	if _, err = SM2PrivateKeyFromPEM(pubPEM); !errors.Is(err, ErrSM2Key) {
+		t.Fatalf("public key PEM as private key: %v", err)
+	}
+}